@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+var (
+	// ownerKey é a chave pública do dono do portfólio (OWNER_PUBLIC_KEY).
+	// nil quando não configurada: ninguém é identificado como dono.
+	ownerKey ssh.PublicKey
+
+	// allowedKeys é a allowlist do modo privado (SSH_AUTHORIZED_KEYS).
+	// nil quando o modo privado está desligado: qualquer um pode entrar.
+	allowedKeys []ssh.PublicKey
+
+	// activeSessions conta as sessões SSH abertas no momento.
+	activeSessions atomic.Int64
+)
+
+// parseOwnerKey lê uma chave pública no formato authorized_keys
+// ("ssh-ed25519 AAAA... comentário").
+func parseOwnerKey(line string) (ssh.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, fmt.Errorf("OWNER_PUBLIC_KEY inválida: %w", err)
+	}
+	return key, nil
+}
+
+// isOwner informa se a sessão foi autenticada com a chave do dono.
+func isOwner(s ssh.Session) bool {
+	return ownerKey != nil && ssh.KeysEqual(s.PublicKey(), ownerKey)
+}
+
+// isAllowed informa se key pode acessar o portfólio.
+// Sem allowlist configurada, todos podem. O dono sempre pode.
+func isAllowed(key ssh.PublicKey) bool {
+	if allowedKeys == nil {
+		return true
+	}
+	if ownerKey != nil && ssh.KeysEqual(key, ownerKey) {
+		return true
+	}
+	for _, k := range allowedKeys {
+		if ssh.KeysEqual(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityOptions habilita a autenticação por chave pública para que
+// s.PublicKey() identifique o dono e a allowlist.
+//
+// Chaves fora da allowlist são recusadas na autenticação, mas clientes sem
+// chave aceita ainda entram por keyboard-interactive: restrictAccess então
+// mostra uma mensagem explicando o modo privado, em vez de um
+// "Permission denied" seco do cliente SSH.
+func identityOptions() []ssh.Option {
+	return []ssh.Option{
+		wish.WithPublicKeyAuth(func(_ ssh.Context, key ssh.PublicKey) bool {
+			return isAllowed(key)
+		}),
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool {
+			return true
+		}),
+	}
+}
+
+// countSessions mantém activeSessions atualizado durante cada sessão.
+func countSessions(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		n := activeSessions.Add(1)
+		log.Debug("Sessão aberta", "user", s.User(), "remote", s.RemoteAddr(), "sessions", n)
+		defer activeSessions.Add(-1)
+		next(s)
+	}
+}
+
+// restrictAccess encerra educadamente sessões sem chave autorizada.
+func restrictAccess(next ssh.Handler) ssh.Handler {
+	return func(s ssh.Session) {
+		if allowedKeys != nil && (s.PublicKey() == nil || !isAllowed(s.PublicKey())) {
+			log.Info("Acesso negado", "user", s.User(), "remote", s.RemoteAddr())
+			wish.Println(s, "Este portfólio está em modo privado e sua chave SSH não está na lista de acesso.")
+			wish.Println(s, "Se você deveria ter acesso, envie sua chave pública para o dono. Até mais!")
+			s.Exit(1)
+			return
+		}
+		next(s)
+	}
+}
+
+// loadAllowedKeys lê a allowlist do arquivo em SSH_AUTHORIZED_KEYS
+// (formato authorized_keys). Sem a variável, o acesso continua aberto.
+func loadAllowedKeys() error {
+	path := os.Getenv("SSH_AUTHORIZED_KEYS")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("lendo SSH_AUTHORIZED_KEYS: %w", err)
+	}
+
+	keys := []ssh.PublicKey{}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		keys = append(keys, key)
+	}
+
+	allowedKeys = keys
+	log.Info("Modo privado ativado", "keys", len(keys), "file", path)
+	return nil
+}
+
+// loadOwnerKey configura ownerKey a partir do ambiente.
+func loadOwnerKey() {
+	line := os.Getenv("OWNER_PUBLIC_KEY")
+	if line == "" {
+		return
+	}
+
+	key, err := parseOwnerKey(line)
+	if err != nil {
+		log.Warn("Dono não será identificado", "error", err)
+		return
+	}
+	ownerKey = key
+	log.Info("Chave do dono configurada")
+}
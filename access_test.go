@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// newKey gera um par de chaves ed25519 para o teste.
+func newKey(t *testing.T) gossh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// withAccess define o dono e a allowlist durante o teste.
+func withAccess(t *testing.T, owner ssh.PublicKey, allowed []ssh.PublicKey) {
+	t.Helper()
+	prevOwner, prevAllowed := ownerKey, allowedKeys
+	ownerKey, allowedKeys = owner, allowed
+	t.Cleanup(func() { ownerKey, allowedKeys = prevOwner, prevAllowed })
+}
+
+func TestIsAllowed(t *testing.T) {
+	owner, listed, stranger := newKey(t).PublicKey(), newKey(t).PublicKey(), newKey(t).PublicKey()
+
+	tests := []struct {
+		name    string
+		allowed []ssh.PublicKey
+		key     ssh.PublicKey
+		want    bool
+	}{
+		{"open mode", nil, stranger, true},
+		{"listed", []ssh.PublicKey{listed}, listed, true},
+		{"not listed", []ssh.PublicKey{listed}, stranger, false},
+		{"empty allowlist", []ssh.PublicKey{}, stranger, false},
+		{"owner outside the allowlist", []ssh.PublicKey{listed}, owner, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withAccess(t, owner, tt.allowed)
+			if got := isAllowed(tt.key); got != tt.want {
+				t.Errorf("isAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAllowedKeys(t *testing.T) {
+	a, b := newKey(t).PublicKey(), newKey(t).PublicKey()
+	authorized := func(key ssh.PublicKey) string {
+		return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key)))
+	}
+	write := func(t *testing.T, content string) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "authorized_keys")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("SSH_AUTHORIZED_KEYS", path)
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		withAccess(t, nil, nil)
+		t.Setenv("SSH_AUTHORIZED_KEYS", "")
+		if err := loadAllowedKeys(); err != nil || allowedKeys != nil {
+			t.Errorf("loadAllowedKeys = %v, keys %v; want open mode", err, allowedKeys)
+		}
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		withAccess(t, nil, nil)
+		write(t, "# amigos\n"+authorized(a)+" ana\n\n  "+authorized(b)+"\n")
+		if err := loadAllowedKeys(); err != nil {
+			t.Fatal(err)
+		}
+		if len(allowedKeys) != 2 || !ssh.KeysEqual(allowedKeys[0], a) || !ssh.KeysEqual(allowedKeys[1], b) {
+			t.Errorf("loaded %d keys, want a and b", len(allowedKeys))
+		}
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		withAccess(t, nil, nil)
+		write(t, authorized(a)+"\nnão é uma chave\n")
+		err := loadAllowedKeys()
+		if err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("loadAllowedKeys = %v, want an error on line 2", err)
+		}
+	})
+}
+
+// TestPrivateModeSSH conecta de verdade num servidor em modo privado: a
+// chave listada entra e a outra recebe a mensagem do modo privado.
+func TestPrivateModeSSH(t *testing.T) {
+	allowed, denied := newKey(t), newKey(t)
+	withAccess(t, nil, []ssh.PublicKey{allowed.PublicKey()})
+
+	opts := []ssh.Option{
+		wish.WithHostKeyPath(filepath.Join(t.TempDir(), "host_key")),
+		wish.WithMiddleware(
+			func(ssh.Handler) ssh.Handler {
+				return func(s ssh.Session) { wish.Println(s, "bem-vindo") }
+			},
+			restrictAccess,
+		),
+	}
+	srv, err := wish.NewServer(append(opts, identityOptions()...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	connect := func(signer gossh.Signer) string {
+		t.Helper()
+		client, err := gossh.Dial("tcp", ln.Addr().String(), &gossh.ClientConfig{
+			User: "visitante",
+			Auth: []gossh.AuthMethod{
+				gossh.PublicKeys(signer),
+				gossh.KeyboardInteractive(func(string, string, []string, []bool) ([]string, error) {
+					return nil, nil
+				}),
+			},
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		out, _ := session.Output("")
+		return string(out)
+	}
+
+	if out := connect(allowed); !strings.Contains(out, "bem-vindo") {
+		t.Errorf("allowed key got %q, want the portfolio", out)
+	}
+	if out := connect(denied); !strings.Contains(out, "modo privado") || strings.Contains(out, "bem-vindo") {
+		t.Errorf("denied key got %q, want the private mode message", out)
+	}
+}
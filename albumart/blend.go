@@ -0,0 +1,136 @@
+package albumart
+
+import (
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cell é um caractere ▀ renderizado: fg é o pixel de cima, bg o de baixo.
+type cell struct {
+	fg, bg color.RGBA
+}
+
+// cellToken casa os pedaços da saída de renderImage: um escape de cor de
+// foreground (38) ou background (48), ou o caractere ▀ de uma célula.
+var cellToken = regexp.MustCompile("\x1b\\[(38|48);2;(\\d+);(\\d+);(\\d+)m|▀")
+
+// parseCells lê de volta as células de uma arte renderizada, linha a linha.
+//
+// As cores são acompanhadas como num terminal: uma célula sem escape
+// próprio herda as da anterior, já que cellWriter só emite mudanças.
+func parseCells(rendered string) [][]cell {
+	lines := strings.Split(rendered, "\n")
+	grid := make([][]cell, len(lines))
+	for i, line := range lines {
+		var cur cell
+		for _, m := range cellToken.FindAllStringSubmatch(line, -1) {
+			if m[1] == "" {
+				grid[i] = append(grid[i], cur)
+				continue
+			}
+			var v [3]uint8
+			for j := range v {
+				n, _ := strconv.Atoi(m[j+2])
+				v[j] = uint8(n)
+			}
+			c := color.RGBA{v[0], v[1], v[2], 255}
+			if m[1] == "38" {
+				cur.fg = c
+			} else {
+				cur.bg = c
+			}
+		}
+	}
+	return grid
+}
+
+// encodeCells converte células de volta para a string ANSI.
+func encodeCells(grid [][]cell) string {
+	w := newCellWriter()
+	for _, line := range grid {
+		for _, c := range line {
+			w.cell(c.fg, c.bg)
+		}
+		w.endLine()
+	}
+	return w.String()
+}
+
+// Blend mistura duas artes renderizadas com o mesmo tamanho, célula a célula.
+// t=0 retorna from, t=1 retorna to. Se os tamanhos não baterem, não há como
+// misturar: retorna a arte mais próxima de t.
+func Blend(from, to string, t float64) string {
+	if t <= 0 {
+		return from
+	}
+	if t >= 1 {
+		return to
+	}
+	return NewBlender(from, to).Blend(t)
+}
+
+// Blender mistura duas artes já lidas. Uma transição chama Blend a cada
+// quadro com as mesmas artes: o Blender as lê uma vez só, em vez de
+// repassar as duas pela regex em todo quadro.
+type Blender struct {
+	from, to string
+	a, b     [][]cell // Células de from e to; nil se não dá para misturar
+}
+
+// NewBlender lê from e to para misturá-las com Blend.
+func NewBlender(from, to string) *Blender {
+	bl := &Blender{from: from, to: to}
+	if a, b := parseCells(from), parseCells(to); sameShape(a, b) {
+		bl.a, bl.b = a, b
+	}
+	return bl
+}
+
+// Of informa se o Blender mistura exatamente from e to.
+func (bl *Blender) Of(from, to string) bool {
+	return bl.from == from && bl.to == to
+}
+
+// Blend é o Blend do pacote para as artes do Blender.
+func (bl *Blender) Blend(t float64) string {
+	if t <= 0 {
+		return bl.from
+	}
+	if t >= 1 {
+		return bl.to
+	}
+	if bl.a == nil {
+		if t < 0.5 {
+			return bl.from
+		}
+		return bl.to
+	}
+
+	a, b := bl.a, bl.b
+	out := make([][]cell, len(a))
+	for y := range a {
+		out[y] = make([]cell, len(a[y]))
+		for x := range a[y] {
+			out[y][x] = cell{
+				fg: lerpRGBA(a[y][x].fg, b[y][x].fg, t),
+				bg: lerpRGBA(a[y][x].bg, b[y][x].bg, t),
+			}
+		}
+	}
+	return encodeCells(out)
+}
+
+func sameShape(a, b [][]cell) bool {
+	// Sem células (ex.: arte em texto puro) não há o que misturar
+	if len(a) != len(b) || len(a) == 0 || len(a[0]) == 0 {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package albumart
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestBlenderMatchesBlend(t *testing.T) {
+	opts := Options{Interpolation: NearestNeighbor}
+	from := renderImage(imageOf([]color.RGBA{red, green}, []color.RGBA{blue, white}), 2, 1, opts)
+	to := renderImage(imageOf([]color.RGBA{white, blue}, []color.RGBA{green, red}), 2, 1, opts)
+	narrow := renderImage(imageOf([]color.RGBA{red}, []color.RGBA{blue}), 1, 1, opts)
+
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{"same shape", from, to},
+		{"different shapes", from, narrow},
+		{"plain text", "sem capa", to},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bl := NewBlender(tt.from, tt.to)
+			if !bl.Of(tt.from, tt.to) || bl.Of(tt.to, tt.from) {
+				t.Error("Of does not match the blended arts")
+			}
+			for _, step := range []float64{-1, 0, 0.25, 0.5, 0.75, 1, 2} {
+				if got, want := bl.Blend(step), Blend(tt.from, tt.to, step); got != want {
+					t.Errorf("Blend(%v) = %q, want %q", step, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlendHalfway(t *testing.T) {
+	from := renderImage(imageOf([]color.RGBA{red}, []color.RGBA{blue}), 1, 1, Options{})
+	to := renderImage(imageOf([]color.RGBA{blue}, []color.RGBA{red}), 1, 1, Options{})
+
+	// 255·0,5 arredonda para 128 nos dois canais que mudam
+	want := "\x1b[38;2;128;0;128m\x1b[48;2;128;0;128m▀" + reset
+	if got := Blend(from, to, 0.5); got != want {
+		t.Errorf("Blend = %q, want %q", got, want)
+	}
+}
+
+func TestBlendProgression(t *testing.T) {
+	from := RenderPlaceholder(PlaceholderLoading, 16, 8, Options{})
+	to := renderImage(gradient(32, 32), 16, 8, Options{})
+	target := parseCells(to)
+
+	// Distância total, canal a canal, até a arte final
+	distance := func(art string) int {
+		d := 0
+		for y, row := range parseCells(art) {
+			for x, c := range row {
+				want := target[y][x]
+				for _, pair := range [][2]uint8{
+					{c.fg.R, want.fg.R}, {c.fg.G, want.fg.G}, {c.fg.B, want.fg.B},
+					{c.bg.R, want.bg.R}, {c.bg.G, want.bg.G}, {c.bg.B, want.bg.B},
+				} {
+					d += abs(int(pair[0]) - int(pair[1]))
+				}
+			}
+		}
+		return d
+	}
+
+	const steps = 8
+	prev := distance(from)
+	for i := 1; i <= steps; i++ {
+		d := distance(Blend(from, to, float64(i)/steps))
+		if d >= prev {
+			t.Fatalf("step %d/%d: distance %d, previous %d; want closer to the art", i, steps, d, prev)
+		}
+		prev = d
+	}
+	if prev != 0 {
+		t.Errorf("last step is %d away from the art, want 0", prev)
+	}
+}
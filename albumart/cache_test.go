@@ -0,0 +1,235 @@
+package albumart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock substitui now por um relógio parado em start, que o teste
+// avança à mão. Limpa o cache (e o limite dele) antes e depois do teste.
+func fakeClock(t *testing.T, start time.Time) *time.Time {
+	t.Helper()
+	clock := start
+	now = func() time.Time { return clock }
+	ClearCache()
+	t.Cleanup(func() {
+		now = time.Now
+		ClearCache()
+	})
+	return &clock
+}
+
+// coverServer serve uma capa PNG 16×16 e conta as requisições.
+func coverServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestCacheTTL(t *testing.T) {
+	clock := fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	srv, hits := coverServer(t)
+
+	render := func() {
+		t.Helper()
+		if _, err := RenderFromURL(srv.URL, 4, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	render()
+	*clock = clock.Add(cacheTTL - time.Second)
+	render()
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("before TTL: %d downloads, want 1", got)
+	}
+
+	*clock = clock.Add(2 * time.Second)
+	render()
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("after TTL: %d downloads, want 2", got)
+	}
+}
+
+// cacheState retorna o tamanho e o limite atuais do cache.
+func cacheState() (size, limit int) {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return len(cache), cacheLimit
+}
+
+func TestCacheChurn(t *testing.T) {
+	clock := fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	// O dono pulando músicas: uma capa nova a cada segundo
+	for i := range 200 {
+		*clock = clock.Add(time.Second)
+		storeInCache(fmt.Sprint("skip", i), "art")
+		if size, limit := cacheState(); size > limit || limit > cacheMaxSize {
+			t.Fatalf("after %d renders: %d entries, limit %d (max %d)", i+1, size, limit, cacheMaxSize)
+		}
+	}
+	if _, limit := cacheState(); limit != cacheMaxSize {
+		t.Errorf("limit during churn = %d, want %d", limit, cacheMaxSize)
+	}
+
+	// Sem despejos por uma janela inteira, o limite encolhe pela metade a
+	// cada capa nova, até voltar ao normal
+	for limit := cacheMaxSize; limit > cacheSize; {
+		*clock = clock.Add(churnWindow)
+		storeInCache(fmt.Sprint("calm", limit), "art")
+		size, got := cacheState()
+		if want := max(limit/2, cacheSize); got != want || size > got {
+			t.Fatalf("after a calm window: limit %d, %d entries; want limit %d", got, size, want)
+		}
+		limit = got
+	}
+}
+
+func TestCacheChurnKeepsRecentArt(t *testing.T) {
+	clock := fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	srv, hits := coverServer(t)
+
+	url := func(i int) string { return fmt.Sprintf("%s/cover%d.png", srv.URL, i) }
+	for i := range 3 * cacheMaxSize {
+		*clock = clock.Add(time.Second)
+		if _, err := RenderFromURL(url(i), 4, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size, _ := cacheState(); size > cacheMaxSize {
+		t.Errorf("%d entries, want at most %d", size, cacheMaxSize)
+	}
+
+	// As capas mais recentes continuam no cache
+	before := hits.Load()
+	for i := 3*cacheMaxSize - cacheSize; i < 3*cacheMaxSize; i++ {
+		if _, err := RenderFromURL(url(i), 4, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := hits.Load() - before; n != 0 {
+		t.Errorf("%d downloads of recent covers, want 0", n)
+	}
+}
+
+func TestClearCacheResetsLimit(t *testing.T) {
+	clock := fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	for i := range 200 {
+		*clock = clock.Add(time.Second)
+		storeInCache(fmt.Sprint("skip", i), "art")
+	}
+	if _, limit := cacheState(); limit != cacheMaxSize {
+		t.Fatalf("limit during churn = %d, want %d", limit, cacheMaxSize)
+	}
+
+	ClearCache()
+	if size, limit := cacheState(); size != 0 || limit != cacheSize {
+		t.Errorf("after ClearCache: %d entries, limit %d; want 0 and %d", size, limit, cacheSize)
+	}
+	cacheMu.RLock()
+	n := len(evictions)
+	cacheMu.RUnlock()
+	if n != 0 {
+		t.Errorf("%d evictions kept after ClearCache, want 0", n)
+	}
+
+	// Sem despejos antigos, uma capa nova não cresce o limite de novo
+	*clock = clock.Add(time.Second)
+	storeInCache("after", "art")
+	if _, limit := cacheState(); limit != cacheSize {
+		t.Errorf("limit after the first new entry = %d, want %d", limit, cacheSize)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	prev := httpClient
+	t.Cleanup(func() { httpClient = prev })
+
+	// O proxy serve a capa ele mesmo: o host da URL nem existe
+	var proxied atomic.Value
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradient(16, 16)); err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(proxy.Close)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	SetProxy(proxyURL)
+	const cover = "http://capas.invalid/proxy.png"
+	if _, err := RenderFromURL(cover, 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := proxied.Load().(string); got != cover {
+		t.Errorf("proxy saw %q, want %q", got, cover)
+	}
+}
+
+func TestCancelDownloads(t *testing.T) {
+	// Cancelar é definitivo: o teste usa um contexto próprio
+	prevCtx, prevCancel := downloadCtx, cancelDownloads
+	downloadCtx, cancelDownloads = context.WithCancel(context.Background())
+	t.Cleanup(func() { downloadCtx, cancelDownloads = prevCtx, prevCancel })
+
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done() // Nunca responde: só o cancelamento solta o download
+	}))
+	t.Cleanup(srv.Close)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := fetchImage(srv.URL + "/slow.png")
+		done <- err
+	}()
+
+	<-started
+	CancelDownloads()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("in-flight download error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("download still running after CancelDownloads")
+	}
+
+	// Os próximos nem chegam ao servidor
+	if _, err := fetchImage(srv.URL + "/next.png"); !errors.Is(err, context.Canceled) {
+		t.Errorf("download after CancelDownloads error = %v, want context.Canceled", err)
+	}
+}
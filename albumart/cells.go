@@ -0,0 +1,73 @@
+package albumart
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// cellWriter monta a string ANSI de uma grade de células ▀, emitindo o
+// escape de foreground ou de background só quando a cor muda em relação à
+// célula anterior. Em áreas lisas (fundos, placeholders, paleta reduzida)
+// a saída encolhe várias vezes sem mudar nada na tela.
+//
+// Cada linha termina com reset e a primeira célula da seguinte emite as
+// duas cores de novo: as linhas não dependem umas das outras, então
+// continuam corretas quando o lipgloss as emoldura ou as junta lado a lado
+// com outro texto.
+type cellWriter struct {
+	sb        strings.Builder
+	fg, bg    color.RGBA
+	lineStart bool // A próxima célula é a primeira da linha
+	lines     int  // Linhas já encerradas
+}
+
+func newCellWriter() *cellWriter {
+	return &cellWriter{lineStart: true}
+}
+
+// cell escreve uma célula com fg na metade de cima e bg na de baixo.
+func (w *cellWriter) cell(fg, bg color.RGBA) {
+	if w.lineStart && w.lines > 0 {
+		w.sb.WriteByte('\n')
+	}
+	if w.lineStart || fg != w.fg {
+		w.color(38, fg)
+	}
+	if w.lineStart || bg != w.bg {
+		w.color(48, bg)
+	}
+	w.sb.WriteString("▀")
+	w.fg, w.bg, w.lineStart = fg, bg, false
+}
+
+// color escreve \x1b[<code>;2;R;G;Bm sem passar por fmt, que dominava o
+// tempo de renderização com uma chamada por célula.
+func (w *cellWriter) color(code int, c color.RGBA) {
+	b := make([]byte, 0, 20)
+	b = append(b, "\x1b["...)
+	b = strconv.AppendInt(b, int64(code), 10)
+	b = append(b, ";2;"...)
+	b = strconv.AppendUint(b, uint64(c.R), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.G), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.B), 10)
+	b = append(b, 'm')
+	w.sb.Write(b)
+}
+
+// endLine encerra a linha atual com um reset.
+func (w *cellWriter) endLine() {
+	if w.lineStart && w.lines > 0 {
+		w.sb.WriteByte('\n')
+	}
+	w.sb.WriteString("\x1b[0m")
+	w.lineStart = true
+	w.lines++
+}
+
+// String retorna as linhas escritas, separadas por \n, sem \n no final.
+func (w *cellWriter) String() string {
+	return w.sb.String()
+}
@@ -0,0 +1,95 @@
+package albumart
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// collageSize é o lado, em pixels, do canvas em que a colagem é montada
+// antes de ser reduzida para a grade de células.
+const collageSize = 600
+
+// RenderCollageFromURLs monta as imagens em urls (2 a 4) numa grade e
+// renderiza como half-blocks, no mesmo formato de RenderFromURLWithOptions:
+//
+//	2: lado a lado   3: uma à esquerda, duas empilhadas à direita   4: 2×2
+//
+// Com menos de 2 URLs, ou se alguma falhar, retorna erro: o chamador deve
+// cair para a capa do álbum.
+func RenderCollageFromURLs(urls []string, width, height int, opts Options) (string, error) {
+	if len(urls) < 2 {
+		return "", fmt.Errorf("collage needs at least 2 images, got %d", len(urls))
+	}
+	urls = urls[:min(len(urls), 4)]
+
+	key := fmt.Sprintf("collage:%s|%dx%d|%+v", strings.Join(urls, ","), width, height, opts)
+	if rendered, ok := lookupCache(key); ok {
+		return rendered, nil
+	}
+
+	imgs := make([]image.Image, len(urls))
+	for i, url := range urls {
+		img, err := fetchImage(url)
+		if err != nil {
+			return "", err
+		}
+		imgs[i] = img
+	}
+
+	rendered := renderImage(collage(imgs, collageSize), width, height, opts)
+	storeInCache(key, rendered)
+	return rendered, nil
+}
+
+// collage compõe imgs (2 a 4) num canvas size×size, recortando o centro
+// de cada imagem na proporção do seu bloco.
+func collage(imgs []image.Image, size int) image.Image {
+	half := size / 2
+	var tiles []image.Rectangle
+	switch len(imgs) {
+	case 2:
+		tiles = []image.Rectangle{
+			image.Rect(0, 0, half, size),
+			image.Rect(half, 0, size, size),
+		}
+	case 3:
+		tiles = []image.Rectangle{
+			image.Rect(0, 0, half, size),
+			image.Rect(half, 0, size, half),
+			image.Rect(half, half, size, size),
+		}
+	default:
+		tiles = []image.Rectangle{
+			image.Rect(0, 0, half, half),
+			image.Rect(half, 0, size, half),
+			image.Rect(0, half, half, size),
+			image.Rect(half, half, size, size),
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i, tile := range tiles {
+		if i >= len(imgs) {
+			break
+		}
+		src := centerCrop(imgs[i].Bounds(), tile.Dx(), tile.Dy())
+		draw.CatmullRom.Scale(dst, tile, imgs[i], src, draw.Src, nil)
+	}
+	return dst
+}
+
+// centerCrop retorna o maior retângulo centrado em b com proporção w×h.
+func centerCrop(b image.Rectangle, w, h int) image.Rectangle {
+	cw, ch := b.Dx(), b.Dy()
+	if cw*h > ch*w {
+		cw = ch * w / h
+	} else {
+		ch = cw * h / w
+	}
+	x := b.Min.X + (b.Dx()-cw)/2
+	y := b.Min.Y + (b.Dy()-ch)/2
+	return image.Rect(x, y, x+cw, y+ch)
+}
@@ -0,0 +1,81 @@
+package albumart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// solidServer serve, em /<nome>.png, uma imagem 16×16 chapada em cada cor
+// de colors. Outros caminhos respondem 404.
+func solidServer(t *testing.T, colors map[string]color.RGBA) *httptest.Server {
+	t.Helper()
+	pngs := map[string][]byte{}
+	for name, c := range colors {
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		for y := range 16 {
+			for x := range 16 {
+				img.SetRGBA(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		pngs["/"+name+".png"] = buf.Bytes()
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := pngs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRenderCollageFromURLs(t *testing.T) {
+	fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	srv := solidServer(t, map[string]color.RGBA{"red": red, "green": green, "blue": blue, "white": white})
+	url := func(name string) string { return srv.URL + "/" + name + ".png" }
+
+	rendered, err := RenderCollageFromURLs([]string{url("red"), url("green"), url("blue"), url("white")}, 8, 4, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := parseCells(rendered)
+	if len(cells) != 4 || len(cells[0]) != 8 {
+		t.Fatalf("collage is %d lines of %d cells, want 4 of 8", len(cells), len(cells[0]))
+	}
+
+	// Um canto de cada quadrante, longe da costura entre eles
+	quadrants := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"top left", 0, 0, red},
+		{"top right", 7, 0, green},
+		{"bottom left", 0, 3, blue},
+		{"bottom right", 7, 3, white},
+	}
+	for _, q := range quadrants {
+		if c := cells[q.y][q.x]; !near(c.fg, q.want, 2) || !near(c.bg, q.want, 2) {
+			t.Errorf("%s cell = %v, want %v", q.name, c, q.want)
+		}
+	}
+
+	// Uma foto que falha derruba a colagem: o chamador cai para a capa
+	rendered, err = RenderCollageFromURLs([]string{url("red"), url("missing")}, 8, 4, Options{})
+	if err == nil || rendered != "" {
+		t.Errorf("collage with a failing URL = %q, %v; want an error", rendered, err)
+	}
+}
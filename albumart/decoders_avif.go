@@ -0,0 +1,8 @@
+//go:build avif
+
+package albumart
+
+// Registra decoder AVIF (-tags avif). O decoder roda a libavif compilada
+// para WebAssembly, sem cgo, mas aumenta bastante o binário: por isso
+// fica atrás de uma build tag, como WebP e GIF.
+import _ "github.com/gen2brain/avif"
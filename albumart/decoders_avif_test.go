@@ -0,0 +1,22 @@
+//go:build avif
+
+package albumart
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/gen2brain/avif"
+)
+
+// avifSample codifica img em AVIF sem perdas, para testar o decoder sem
+// guardar um arquivo binário em testdata.
+func avifSample(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := avif.Encode(&buf, img, avif.Options{Lossless: true, Speed: 10}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
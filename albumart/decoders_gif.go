@@ -0,0 +1,5 @@
+//go:build gif
+
+package albumart
+
+import _ "image/gif" // Registra decoder GIF (-tags gif)
@@ -0,0 +1,13 @@
+//go:build !avif
+
+package albumart
+
+import (
+	"image"
+	"testing"
+)
+
+// avifSample retorna nil: sem -tags avif não há encoder nem decoder.
+func avifSample(t *testing.T, img image.Image) []byte {
+	return nil
+}
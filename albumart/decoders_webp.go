@@ -0,0 +1,5 @@
+//go:build webp
+
+package albumart
+
+import _ "golang.org/x/image/webp" // Registra decoder WebP (-tags webp)
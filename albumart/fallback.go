@@ -0,0 +1,80 @@
+package albumart
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+)
+
+// FallbackEmbedded é o valor de LoadFallbackImage que seleciona a imagem
+// embutida no binário (um disco de vinil), em vez de um arquivo.
+const FallbackEmbedded = "embedded"
+
+//go:embed fallback.png
+var embeddedFallback []byte
+
+// fallbackImage substitui o placeholder procedural quando a música não
+// tem capa ou o download falha. nil mantém o placeholder.
+var (
+	fallbackMu    sync.RWMutex
+	fallbackImage image.Image
+)
+
+// SetFallbackImage define a imagem mostrada no lugar de capas ausentes ou
+// com erro; nil volta ao placeholder procedural. O placeholder de
+// carregamento não muda: ele diz que a capa de verdade está a caminho.
+func SetFallbackImage(img image.Image) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	fallbackImage = img
+}
+
+// LoadFallbackImage carrega a imagem de fallback de source, um caminho
+// de arquivo ou FallbackEmbedded, e a define com SetFallbackImage. Em caso
+// de erro a imagem atual é mantida.
+func LoadFallbackImage(source string) error {
+	data := embeddedFallback
+	if source != FallbackEmbedded {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return err
+		}
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return err
+	}
+	if degenerate(img) {
+		return fmt.Errorf("fallback image too small: %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	SetFallbackImage(img)
+	return nil
+}
+
+// currentFallback retorna a imagem de fallback, ou nil se não houver.
+func currentFallback() image.Image {
+	fallbackMu.RLock()
+	defer fallbackMu.RUnlock()
+	return fallbackImage
+}
+
+// renderFallback renderiza a imagem de fallback pelo caminho normal, com
+// opts, ou o placeholder de kind se não houver imagem configurada.
+func renderFallback(kind PlaceholderKind, width, height int, opts Options) string {
+	if img := currentFallback(); img != nil {
+		return renderImage(img, width, height, opts)
+	}
+	return renderPlaceholder(kind, width, height, opts.Placeholder)
+}
+
+// renderSketchFallback é o equivalente de renderFallback no modo esboço.
+func renderSketchFallback(kind PlaceholderKind, width, height int) string {
+	if img := currentFallback(); img != nil {
+		return renderSketch(img, width, height)
+	}
+	return RenderSketchPlaceholder(kind, width, height)
+}
@@ -0,0 +1,75 @@
+package albumart
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withFallback restaura a imagem de fallback ao fim do teste.
+func withFallback(t *testing.T) {
+	t.Helper()
+	prev := currentFallback()
+	t.Cleanup(func() { SetFallbackImage(prev) })
+}
+
+func TestEmbeddedFallback(t *testing.T) {
+	withFallback(t)
+	if err := LoadFallbackImage(FallbackEmbedded); err != nil {
+		t.Fatal(err)
+	}
+
+	const width, height = 16, 8
+	placeholder := RenderPlaceholder(PlaceholderMissing, width, height, Options{})
+	out, err := RenderFromURL("", width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == placeholder {
+		t.Error("empty URL still renders the procedural placeholder")
+	}
+	if want := renderImage(currentFallback(), width, height, Options{}); out != want {
+		t.Error("empty URL does not render the embedded image")
+	}
+
+	// Download com erro também mostra a imagem, junto com o erro
+	out, err = RenderFromURL("http://127.0.0.1:0/cover", width, height)
+	if err == nil {
+		t.Error("failed download returned no error")
+	}
+	if out == RenderPlaceholder(PlaceholderFailed, width, height, Options{}) {
+		t.Error("failed download still renders the procedural placeholder")
+	}
+
+	SetFallbackImage(nil)
+	if out, _ := RenderFromURL("", width, height); out != placeholder {
+		t.Error("clearing the fallback does not restore the placeholder")
+	}
+}
+
+func TestLoadFallbackImageErrors(t *testing.T) {
+	withFallback(t)
+	SetFallbackImage(nil)
+
+	dir := t.TempDir()
+	tiny := filepath.Join(dir, "tiny.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imageOf([]color.RGBA{red})); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tiny, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{filepath.Join(dir, "missing.png"), tiny} {
+		if err := LoadFallbackImage(source); err == nil {
+			t.Errorf("LoadFallbackImage(%q) succeeded", filepath.Base(source))
+		}
+		if currentFallback() != nil {
+			t.Errorf("LoadFallbackImage(%q) replaced the current image", filepath.Base(source))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package albumart
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+// Fit define como imagens retrato (mais altas que a área da arte) se
+// encaixam na grade de células.
+type Fit int
+
+const (
+	FitStretch   Fit = iota // Estica para preencher a área (padrão, distorce)
+	FitCrop                 // Recorta o centro na proporção da área
+	FitLetterbox            // Mantém a imagem inteira, com faixas laterais
+)
+
+// ParseFit converte um nome ("stretch", "crop", "letterbox") em Fit.
+func ParseFit(name string) (Fit, bool) {
+	switch name {
+	case "stretch":
+		return FitStretch, true
+	case "crop":
+		return FitCrop, true
+	case "letterbox":
+		return FitLetterbox, true
+	}
+	return FitStretch, false
+}
+
+// fitPortrait ajusta img à proporção width×height (em pixels) quando ela
+// é mais alta que a área. Imagens quadradas ou paisagem passam intactas.
+// As faixas do letterbox usam bg.
+func fitPortrait(img image.Image, width, height int, fit Fit, bg color.RGBA) image.Image {
+	b := img.Bounds()
+	if fit == FitStretch || b.Dx()*height >= width*b.Dy() {
+		return img
+	}
+
+	// Largura que a imagem teria na proporção da área, mantendo a altura
+	targetW := b.Dy() * width / height
+
+	if fit == FitCrop {
+		// Altura do recorte na proporção da área, mantendo a largura
+		cropH := b.Dx() * height / width
+		top := b.Min.Y + (b.Dy()-cropH)/2
+		crop := image.Rect(b.Min.X, top, b.Max.X, top+cropH)
+
+		if sub, ok := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}); ok {
+			return sub.SubImage(crop)
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+		draw.Draw(dst, dst.Bounds(), img, crop.Min, draw.Src)
+		return dst
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, targetW, b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	left := (targetW - b.Dx()) / 2
+	draw.Draw(dst, image.Rect(left, 0, left+b.Dx(), b.Dy()), img, b.Min, draw.Over)
+	return dst
+}
+
+// correctAspect encaixa img, sem distorcer, numa grade de width×height
+// células cuja altura é ratio vezes a largura. Sobra vira faixa bg.
+//
+// Com half-blocks cada pixel tem 1 coluna de largura e ratio/2 de altura:
+// só com ratio 2 os pixels são quadrados. Em fontes com outra proporção a
+// capa aparece esticada; aqui a imagem é posta num canvas com a proporção
+// física da grade, que depois é esticado para ela.
+func correctAspect(img image.Image, width, height int, ratio float64, bg color.RGBA) image.Image {
+	b := img.Bounds()
+	grid := float64(width) / (float64(height) * ratio) // Proporção física (largura/altura) da grade
+	src := float64(b.Dx()) / float64(b.Dy())
+
+	canvasW, canvasH := b.Dx(), b.Dy()
+	if src > grid {
+		canvasH = int(float64(b.Dx())/grid + 0.5)
+	} else {
+		canvasW = int(float64(b.Dy())*grid + 0.5)
+	}
+	if canvasW == b.Dx() && canvasH == b.Dy() {
+		return img
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, canvasW, canvasH))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	left, top := (canvasW-b.Dx())/2, (canvasH-b.Dy())/2
+	draw.Draw(dst, image.Rect(left, top, left+b.Dx(), top+b.Dy()), img, b.Min, draw.Over)
+	return dst
+}
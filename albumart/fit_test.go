@@ -0,0 +1,150 @@
+package albumart
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// tallImage é uma imagem 8×16 com o quarto de cima azul, o de baixo verde
+// e o meio vermelho.
+func tallImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 16))
+	for y := range 16 {
+		c := red
+		switch {
+		case y < 4:
+			c = blue
+		case y >= 12:
+			c = green
+		}
+		for x := range 8 {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// colorAt lê o pixel (x, y) relativo ao canto de img.
+func colorAt(img image.Image, x, y int) color.RGBA {
+	b := img.Bounds()
+	return color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+}
+
+func TestFitPortrait(t *testing.T) {
+	bg := color.RGBA{10, 20, 30, 255}
+
+	t.Run("stretch", func(t *testing.T) {
+		img := tallImage()
+		if got := fitPortrait(img, 16, 16, FitStretch, bg); got != image.Image(img) {
+			t.Error("stretch changed the image")
+		}
+	})
+
+	t.Run("crop", func(t *testing.T) {
+		got := fitPortrait(tallImage(), 16, 16, FitCrop, bg)
+		if b := got.Bounds(); b.Dx() != 8 || b.Dy() != 8 {
+			t.Fatalf("cropped to %dx%d, want 8x8", b.Dx(), b.Dy())
+		}
+		// O recorte é o centro: só o meio vermelho sobra
+		for y := range 8 {
+			for x := range 8 {
+				if c := colorAt(got, x, y); c != red {
+					t.Fatalf("pixel (%d, %d) = %v, want red", x, y, c)
+				}
+			}
+		}
+	})
+
+	t.Run("letterbox", func(t *testing.T) {
+		got := fitPortrait(tallImage(), 16, 16, FitLetterbox, bg)
+		if b := got.Bounds(); b.Dx() != 16 || b.Dy() != 16 {
+			t.Fatalf("letterboxed to %dx%d, want 16x16", b.Dx(), b.Dy())
+		}
+		// A imagem inteira no meio, sem escala, com faixas bg dos lados
+		for y := range 16 {
+			for x := range 16 {
+				want := bg
+				if x >= 4 && x < 12 {
+					want = colorAt(tallImage(), x-4, y)
+				}
+				if c := colorAt(got, x, y); c != want {
+					t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, c, want)
+				}
+			}
+		}
+	})
+
+	t.Run("square passes", func(t *testing.T) {
+		img := gradient(16, 16)
+		for _, fit := range []Fit{FitCrop, FitLetterbox} {
+			if got := fitPortrait(img, 16, 16, fit, bg); got != image.Image(img) {
+				t.Errorf("fit %d changed a square image", fit)
+			}
+		}
+	})
+}
+
+func TestRenderFitPortrait(t *testing.T) {
+	// Renderizado, as faixas do letterbox usam o fundo do placeholder
+	bg := color.RGBA{10, 20, 30, 255}
+	opts := Options{Interpolation: NearestNeighbor, Fit: FitLetterbox, Placeholder: PlaceholderColors{FG: white, BG: bg}}
+	got := parseCells(renderImage(tallImage(), 16, 8, opts))
+	for y, line := range got {
+		if line[0].fg != bg || line[15].bg != bg {
+			t.Errorf("line %d edges = %v, %v; want the placeholder background %v", y, line[0], line[15], bg)
+		}
+		if line[8].fg == bg {
+			t.Errorf("line %d center is background, want the image", y)
+		}
+	}
+}
+
+func TestCorrectAspect(t *testing.T) {
+	bg := color.RGBA{10, 20, 30, 255}
+	square := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range square.Pix {
+		square.Pix[i] = 0xff
+	}
+
+	tests := []struct {
+		name      string
+		ratio     float64
+		wantW     int
+		wantH     int
+		left, top int
+		unchanged bool
+	}{
+		// 20×10 células com ratio 2 têm a proporção física 1:1 da imagem
+		{"square pixels", 2, 16, 16, 0, 0, true},
+		// Células mais altas: a grade fica mais estreita, sobra em cima e embaixo
+		{"tall cells", 2.5, 16, 20, 0, 2, false},
+		// Células mais baixas: a grade fica mais larga, sobra dos lados
+		{"short cells", 1.6, 20, 16, 2, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := correctAspect(square, 20, 10, tt.ratio, bg)
+			if tt.unchanged && got != image.Image(square) {
+				t.Error("image changed with square pixels")
+			}
+			b := got.Bounds()
+			if b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Fatalf("canvas %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			for y := range b.Dy() {
+				for x := range b.Dx() {
+					inside := x >= tt.left && x < tt.left+16 && y >= tt.top && y < tt.top+16
+					want := bg
+					if inside {
+						want = white
+					}
+					if c := colorAt(got, x, y); c != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, c, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package albumart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+)
+
+// ErrUnsupportedFormat indica que a imagem está num formato sem decoder
+// registrado neste build. A mensagem completa nomeia o formato detectado.
+//
+// JPEG e PNG estão sempre disponíveis; outros formatos são habilitados
+// por build tags (ex.: go build -tags webp,gif,avif).
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// formatSignatures identifica formatos conhecidos pelos primeiros bytes,
+// para nomear o formato mesmo quando não há decoder registrado para ele.
+var formatSignatures = []struct {
+	name   string
+	offset int
+	magic  []byte
+}{
+	{"jpeg", 0, []byte("\xff\xd8\xff")},
+	{"png", 0, []byte("\x89PNG\r\n\x1a\n")},
+	{"gif", 0, []byte("GIF8")},
+	{"webp", 8, []byte("WEBP")},
+	{"avif", 4, []byte("ftypavif")},
+	{"avif", 4, []byte("ftypavis")},
+	{"bmp", 0, []byte("BM")},
+	{"tiff", 0, []byte("II*\x00")},
+	{"tiff", 0, []byte("MM\x00*")},
+}
+
+// sniffFormat retorna o nome do formato de header, ou "" se desconhecido.
+func sniffFormat(header []byte) string {
+	for _, sig := range formatSignatures {
+		end := sig.offset + len(sig.magic)
+		if len(header) >= end && bytes.Equal(header[sig.offset:end], sig.magic) {
+			return sig.name
+		}
+	}
+	return ""
+}
+
+// unsupportedFormat monta o erro para uma imagem cujo decoder não está
+// registrado, sugerindo a build tag correspondente quando o formato é conhecido.
+func unsupportedFormat(header []byte) error {
+	name := sniffFormat(header)
+	if name == "" {
+		return fmt.Errorf("%w: unknown", ErrUnsupportedFormat)
+	}
+	return fmt.Errorf("%w: %s (build with -tags %s to enable it)", ErrUnsupportedFormat, name, name)
+}
+
+// decode decodifica a imagem em data, convertendo image.ErrFormat em
+// ErrUnsupportedFormat com o nome do formato detectado.
+//
+// JPEGs em CMYK (comuns em capas exportadas por ferramentas de gráfica)
+// chegam como *image.CMYK, já com a inversão do Adobe APP14 desfeita pelo
+// image/jpeg, e YCbCr chega como *image.YCbCr: o render converte os dois
+// por At().RGBA(), sem caminho especial. Só o CMYK sem o marcador Adobe,
+// que o image/jpeg não sabe interpretar, vira ErrUnsupportedFormat.
+func decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, unsupportedFormat(data)
+	}
+	var unsupported jpeg.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return nil, fmt.Errorf("%w: jpeg (%s)", ErrUnsupportedFormat, string(unsupported))
+	}
+	return img, err
+}
@@ -0,0 +1,48 @@
+package albumart
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAVIF(t *testing.T) {
+	data := avifSample(t, gradient(16, 16))
+	if data == nil {
+		t.Skip("AVIF decoder needs -tags avif")
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 16 {
+		t.Fatalf("decoded %dx%d, want 16x16", b.Dx(), b.Dy())
+	}
+	want := gradient(16, 16)
+	for _, p := range [][2]int{{0, 0}, {15, 0}, {0, 15}, {15, 15}} {
+		got := color.RGBAModel.Convert(img.At(p[0], p[1])).(color.RGBA)
+		if w := want.RGBAAt(p[0], p[1]); !near(got, w, 8) {
+			t.Errorf("pixel %v = %v, want about %v", p, got, w)
+		}
+	}
+}
+
+func TestUnsupportedAVIFHint(t *testing.T) {
+	if avifSample(t, imageOf([]color.RGBA{red})) != nil {
+		t.Skip("AVIF decoder registered with -tags avif")
+	}
+
+	header := []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00")
+	_, err := decode(header)
+	if !errors.Is(err, ErrUnsupportedFormat) || !strings.Contains(err.Error(), "-tags avif") {
+		t.Errorf("decode(AVIF) = %v, want ErrUnsupportedFormat with the avif tag hint", err)
+	}
+}
+
+// near informa se cada canal de a está a no máximo tol de b.
+func near(a, b color.RGBA, tol int) bool {
+	d := func(x, y uint8) bool { return max(int(x)-int(y), int(y)-int(x)) <= tol }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B)
+}
@@ -0,0 +1,103 @@
+package albumart
+
+import (
+	"image/color"
+	"strings"
+)
+
+// PlaceholderKind diz por que não há capa para mostrar. Cada tipo tem um
+// desenho discreto próprio, nas cores do placeholder, para que a interface
+// diferencie "sem capa" de "carregando" e de "falhou".
+type PlaceholderKind int
+
+const (
+	PlaceholderMissing PlaceholderKind = iota // A música não tem capa: blocos lisos
+	PlaceholderLoading                        // A capa ainda está baixando: listras diagonais
+	PlaceholderFailed                         // Download ou decodificação falhou: um X no centro
+)
+
+// RenderPlaceholder renderiza o placeholder do tipo kind com as cores de
+// opts.Placeholder (zero usa o cinza padrão).
+func RenderPlaceholder(kind PlaceholderKind, width, height int, opts Options) string {
+	return renderPlaceholder(kind, width, height, opts.Placeholder)
+}
+
+// RenderSketchPlaceholder é o equivalente de RenderPlaceholder para o modo
+// esboço: espaços para PlaceholderMissing, pontos espaçados para
+// PlaceholderLoading e um X de traços para PlaceholderFailed.
+func RenderSketchPlaceholder(kind PlaceholderKind, width, height int) string {
+	var sb strings.Builder
+	for y := range height {
+		for x := range width {
+			switch {
+			case !placeholderMark(kind, x, y, width, height):
+				sb.WriteByte(' ')
+			case kind == PlaceholderLoading:
+				sb.WriteByte('.')
+			case onDiagonal(x, y, width, height):
+				sb.WriteByte('\\')
+			default:
+				sb.WriteByte('/')
+			}
+		}
+		if y < height-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// renderPlaceholder desenha o placeholder em half-blocks: pixels marcados
+// por placeholderMark usam a cor FG, os demais a cor BG. O placeholder
+// liso mantém o visual original, com FG em cima e BG embaixo.
+func renderPlaceholder(kind PlaceholderKind, width, height int, colors PlaceholderColors) string {
+	if colors == (PlaceholderColors{}) {
+		colors = defaultPlaceholder
+	}
+
+	pixelHeight := height * 2
+	pixel := func(x, y int) color.RGBA {
+		if kind == PlaceholderMissing && y%2 == 0 || placeholderMark(kind, x, y, width, pixelHeight) {
+			return colors.FG
+		}
+		return colors.BG
+	}
+
+	w := newCellWriter()
+	for y := 0; y < pixelHeight; y += 2 {
+		for x := range width {
+			w.cell(pixel(x, y), pixel(x, y+1))
+		}
+		w.endLine()
+	}
+	return w.String()
+}
+
+// placeholderMark informa se o ponto (x, y) de uma grade w×h faz parte do
+// desenho do placeholder do tipo kind.
+func placeholderMark(kind PlaceholderKind, x, y, w, h int) bool {
+	switch kind {
+	case PlaceholderLoading:
+		return (x+y)%6 == 0
+	case PlaceholderFailed:
+		// Só o terço central, para o X ficar discreto
+		if abs(2*x-w+1) > w/3 || abs(2*y-h+1) > h/3 {
+			return false
+		}
+		return onDiagonal(x, y, w, h) || onDiagonal(x, h-1-y, w, h)
+	}
+	return false
+}
+
+// onDiagonal informa se (x, y) está a menos de meio ponto da diagonal que
+// vai de (0, 0) a (w-1, h-1), medido no eixo mais longo.
+func onDiagonal(x, y, w, h int) bool {
+	return 2*abs(x*(h-1)-y*(w-1)) < max(w, h)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package albumart
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var placeholderKinds = map[PlaceholderKind]string{
+	PlaceholderMissing: "missing",
+	PlaceholderLoading: "loading",
+	PlaceholderFailed:  "failed",
+}
+
+func TestPlaceholdersDiffer(t *testing.T) {
+	const width, height = 16, 8
+
+	renderers := map[string]func(PlaceholderKind) string{
+		"blocks": func(kind PlaceholderKind) string { return RenderPlaceholder(kind, width, height, Options{}) },
+		"sketch": func(kind PlaceholderKind) string { return RenderSketchPlaceholder(kind, width, height) },
+	}
+	for name, render := range renderers {
+		t.Run(name, func(t *testing.T) {
+			seen := map[string]string{}
+			for kind, kindName := range placeholderKinds {
+				out := render(kind)
+				if lines := strings.Split(out, "\n"); len(lines) != height {
+					t.Errorf("%s: %d lines, want %d", kindName, len(lines), height)
+				}
+				if other, dup := seen[out]; dup {
+					t.Errorf("%s and %s render the same", kindName, other)
+				}
+				seen[out] = kindName
+			}
+		})
+	}
+}
+
+func TestPlaceholderColors(t *testing.T) {
+	colors := PlaceholderColors{FG: red, BG: blue}
+	for kind, name := range placeholderKinds {
+		out := RenderPlaceholder(kind, 16, 8, Options{Placeholder: colors})
+		for _, row := range parseCells(out) {
+			for _, c := range row {
+				for _, px := range []color.RGBA{c.fg, c.bg} {
+					if px != red && px != blue {
+						t.Fatalf("%s: pixel %v outside the placeholder colors", name, px)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTinySourceRendersMissing(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imageOf([]color.RGBA{red})); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	const width, height = 8, 4
+	out, err := RenderFromURL(srv.URL+"/pixel", width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := RenderPlaceholder(PlaceholderMissing, width, height, Options{}); out != want {
+		t.Errorf("1x1 image rendered %q, want the missing placeholder", out)
+	}
+
+	sketch, err := RenderSketchFromURL(srv.URL+"/pixel", width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := RenderSketchPlaceholder(PlaceholderMissing, width, height); sketch != want {
+		t.Errorf("1x1 image sketched %q, want the missing placeholder", sketch)
+	}
+}
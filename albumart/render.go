@@ -8,12 +8,17 @@
 package albumart
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"image"
+	"image/color"
 	_ "image/jpeg" // Registra decoder JPEG
 	_ "image/png"  // Registra decoder PNG
+	"io"
+	"math"
 	"net/http"
-	"strings"
+	"net/url"
 	"sync"
 	"time"
 
@@ -22,11 +27,25 @@ import (
 
 // Cache armazena imagens já renderizadas para evitar re-download.
 // Usa LRU simples com TTL de 5 minutos e máximo de 10 entradas.
+//
+// Quando muitas entradas são despejadas em pouco tempo (ex.: o dono
+// pulando músicas rápido), o limite cresce temporariamente até
+// cacheMaxSize para absorver o pico, e volta a cacheSize quando os
+// despejos param.
 var (
 	cache     = make(map[string]cacheEntry)
 	cacheMu   sync.RWMutex
 	cacheTTL  = 5 * time.Minute
 	cacheSize = 10
+
+	cacheMaxSize = 40          // Limite máximo durante picos de troca
+	churnWindow  = time.Minute // Janela para medir a taxa de despejos
+	cacheLimit   = cacheSize   // Limite atual (entre cacheSize e cacheMaxSize)
+	evictions    []time.Time   // Despejos recentes, dentro de churnWindow
+
+	// now é o relógio do cache. Substituível para controlar TTL e
+	// janela de despejos sem esperar o tempo passar.
+	now = time.Now
 )
 
 // cacheEntry armazena uma imagem renderizada e quando foi criada.
@@ -35,6 +54,108 @@ type cacheEntry struct {
 	timestamp time.Time // Quando foi cacheado
 }
 
+// Options controla como a imagem é convertida em blocos.
+// O valor zero corresponde à renderização padrão, em cores.
+type Options struct {
+	Grayscale   bool              // Converte cada pixel para luminância (tons de cinza true color)
+	Placeholder PlaceholderColors // Cores do placeholder; zero usa o cinza padrão
+
+	// Density é a fração da resolução da grade de células efetivamente
+	// amostrada (0 < Density ≤ 1). 1 (ou zero) é a resolução cheia;
+	// 0.5 amostra metade dos pixels em cada eixo, com menos cores distintas.
+	Density float64
+
+	// CornerRadius arredonda os cantos da arte, em pixels (1 coluna = 1 pixel),
+	// misturando os pixels fora do arco em direção a CornerColor. Com
+	// CornerColor igual à cor da moldura, a arte acompanha a RoundedBorder.
+	// Zero desliga.
+	CornerRadius int
+	CornerColor  color.RGBA
+
+	// Fit define o encaixe de capas retrato; zero (FitStretch) estica.
+	// As faixas do FitLetterbox usam a cor de fundo do placeholder.
+	Fit Fit
+
+	// Interpolation é o algoritmo de redimensionamento; zero usa Catmull-Rom.
+	Interpolation Interpolation
+
+	// CellRatio é a proporção altura/largura de uma célula do terminal
+	// (tipicamente ~2). Quando definido, a capa mantém a proporção original
+	// na tela, com faixas na cor de fundo do placeholder. Zero estica a
+	// imagem para a grade, como se as células fossem exatamente 1:2.
+	CellRatio float64
+
+	// Background é a cor sobre a qual pixels transparentes (PNG com alpha)
+	// são compostos. Zero compõe sobre preto.
+	Background color.RGBA
+
+	// Colors reduz a paleta a cerca de Colors cores (ex.: 64 ou 256),
+	// uniformes em cada canal. Com menos cores, células vizinhas repetem
+	// a cor com frequência, e como o escape só é emitido quando ela muda
+	// (ver cellWriter), a saída encolhe bastante em links lentos. Zero
+	// mantém true color.
+	Colors int
+}
+
+// quantizeLevels retorna quantos níveis por canal dão cerca de colors cores.
+func quantizeLevels(colors int) int {
+	return max(int(math.Round(math.Cbrt(float64(colors)))), 2)
+}
+
+// quantize arredonda v (0-255) para o nível mais próximo entre levels
+// níveis uniformes.
+func quantize(v uint32, levels int) uint32 {
+	step := 255 / float64(levels-1)
+	return uint32(math.Round(math.Round(float64(v)/step) * step))
+}
+
+// Interpolation escolhe o algoritmo usado para redimensionar a capa.
+type Interpolation int
+
+const (
+	CatmullRom      Interpolation = iota // Suave, melhor para fotos (padrão)
+	Bilinear                             // Mais rápido, um pouco mais borrado
+	NearestNeighbor                      // Pixels nítidos, melhor para pixel art
+)
+
+// ParseInterpolation converte um nome ("catmullrom", "bilinear",
+// "nearest") em Interpolation.
+func ParseInterpolation(name string) (Interpolation, bool) {
+	switch name {
+	case "catmullrom":
+		return CatmullRom, true
+	case "bilinear":
+		return Bilinear, true
+	case "nearest":
+		return NearestNeighbor, true
+	}
+	return CatmullRom, false
+}
+
+// scaler retorna o draw.Scaler correspondente.
+func (i Interpolation) scaler() draw.Scaler {
+	switch i {
+	case Bilinear:
+		return draw.BiLinear
+	case NearestNeighbor:
+		return draw.NearestNeighbor
+	default:
+		return draw.CatmullRom
+	}
+}
+
+// PlaceholderColors define as cores dos blocos do placeholder.
+type PlaceholderColors struct {
+	FG color.RGBA // Metade superior (foreground)
+	BG color.RGBA // Metade inferior (background)
+}
+
+// defaultPlaceholder é o cinza usado quando Options.Placeholder é zero.
+var defaultPlaceholder = PlaceholderColors{
+	FG: color.RGBA{60, 60, 60, 255},
+	BG: color.RGBA{40, 40, 40, 255},
+}
+
 // RenderFromURL baixa uma imagem e renderiza como blocos Unicode coloridos.
 //
 // Parâmetros:
@@ -43,48 +164,101 @@ type cacheEntry struct {
 //   - height: altura em linhas (cada linha = 2 pixels)
 //
 // Fluxo:
-//   1. Verifica cache
-//   2. Se não cacheado, baixa imagem via HTTP
-//   3. Decodifica JPEG/PNG
-//   4. Redimensiona para width × (height×2) pixels
-//   5. Converte para string com códigos ANSI
-//   6. Armazena no cache
-//   7. Retorna string renderizada
+//  1. Verifica cache
+//  2. Se não cacheado, baixa imagem via HTTP
+//  3. Decodifica JPEG/PNG
+//  4. Imagens menores que minSourceSize (ex.: pixel de rastreamento 1×1)
+//     são tratadas como capa ausente
+//  5. Redimensiona para width × (height×2) pixels
+//  6. Converte para string com códigos ANSI
+//  7. Armazena no cache
+//  8. Retorna string renderizada
+//
+// Com URL vazia ou erro, retorna a imagem de fallback (ver SetFallbackImage)
+// renderizada pelo mesmo caminho ou, sem ela, o placeholder.
 func RenderFromURL(url string, width, height int) (string, error) {
+	return RenderFromURLWithOptions(url, width, height, Options{})
+}
+
+// RenderFromURLWithOptions funciona como RenderFromURL, aplicando opts na conversão.
+// Cada combinação de URL e opções é cacheada separadamente.
+func RenderFromURLWithOptions(url string, width, height int, opts Options) (string, error) {
 	if url == "" {
-		return renderPlaceholder(width, height), nil
+		return renderFallback(PlaceholderMissing, width, height, opts), nil
 	}
 
-	// Check cache
-	cacheMu.RLock()
-	if entry, ok := cache[url]; ok {
-		if time.Since(entry.timestamp) < cacheTTL {
-			cacheMu.RUnlock()
-			return entry.rendered, nil
+	key := fmt.Sprintf("%s|%dx%d|%+v", url, width, height, opts)
+
+	timings := newTimings()
+	rendered, err := renderCached(key, url, timings, func(img image.Image) string {
+		if degenerate(img) {
+			return renderFallback(PlaceholderMissing, width, height, opts)
 		}
+		rendered := renderImageTimed(img, width, height, opts, timings)
+		timings.report(url)
+		return rendered
+	})
+	if err != nil {
+		return renderFallback(PlaceholderFailed, width, height, opts), err
 	}
-	cacheMu.RUnlock()
 
-	// Download image
-	resp, err := http.Get(url)
-	if err != nil {
-		return renderPlaceholder(width, height), err
+	return rendered, nil
+}
+
+// minSourceSize é o menor lado, em pixels, de uma imagem tratada como capa.
+// Abaixo disso é quase sempre um pixel de rastreamento ou placeholder do
+// servidor, e ampliá-lo só produziria uma cor chapada.
+const minSourceSize = 8
+
+// degenerate informa se img é pequena demais para ser uma capa de verdade.
+func degenerate(img image.Image) bool {
+	b := img.Bounds()
+	return b.Dx() < minSourceSize || b.Dy() < minSourceSize
+}
+
+// renderCached retorna a renderização cacheada em key ou, se não houver
+// (ou tiver expirado), baixa a imagem de url, renderiza com render e cacheia.
+// Com timings não nil, mede o download e a decodificação.
+func renderCached(key, url string, timings *Timings, render func(image.Image) string) (string, error) {
+	if rendered, ok := lookupCache(key); ok {
+		return rendered, nil
 	}
-	defer resp.Body.Close()
 
-	// Decode image
-	img, _, err := image.Decode(resp.Body)
+	img, err := fetchImageTimed(url, timings)
 	if err != nil {
-		return renderPlaceholder(width, height), err
+		return "", err
 	}
 
-	// Render to Unicode blocks
-	rendered := renderImage(img, width, height)
+	rendered := render(img)
+	storeInCache(key, rendered)
+
+	return rendered, nil
+}
+
+// lookupCache retorna a renderização em key, se existir e não tiver expirado.
+func lookupCache(key string) (string, bool) {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+
+	entry, ok := cache[key]
+	if !ok || now().Sub(entry.timestamp) >= cacheTTL {
+		return "", false
+	}
+	return entry.rendered, true
+}
+
+// storeInCache guarda rendered em key, despejando as entradas mais antigas
+// se o cache estiver cheio.
+func storeInCache(key, rendered string) {
+	stored := now()
 
-	// Store in cache
 	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	shrunk := adjustCacheLimit(stored)
+
 	// Clean old entries if cache is full
-	if len(cache) >= cacheSize {
+	for len(cache) >= cacheLimit {
 		var oldestKey string
 		var oldestTime time.Time
 		for k, v := range cache {
@@ -94,100 +268,333 @@ func RenderFromURL(url string, width, height int) (string, error) {
 			}
 		}
 		delete(cache, oldestKey)
+		// Despejos causados pelo encolhimento não contam como troca
+		if !shrunk {
+			evictions = append(evictions, stored)
+		}
 	}
-	cache[url] = cacheEntry{rendered: rendered, timestamp: time.Now()}
-	cacheMu.Unlock()
+	cache[key] = cacheEntry{rendered: rendered, timestamp: stored}
+}
 
-	return rendered, nil
+// adjustCacheLimit dobra o limite do cache quando um cache inteiro foi
+// despejado dentro de churnWindow, e o reduz pela metade quando não houve
+// despejos na janela. Retorna true se o limite encolheu.
+//
+// Os despejos que fizeram o limite crescer continuam contando até saírem
+// da janela: zerá-los faria a próxima chamada ver uma janela sem despejos
+// e desfazer o crescimento na hora.
+// Deve ser chamado com cacheMu travado.
+func adjustCacheLimit(at time.Time) bool {
+	recent := evictions[:0]
+	for _, t := range evictions {
+		if at.Sub(t) < churnWindow {
+			recent = append(recent, t)
+		}
+	}
+	evictions = recent
+
+	switch {
+	case len(evictions) >= cacheLimit && cacheLimit < cacheMaxSize:
+		cacheLimit = min(cacheLimit*2, cacheMaxSize)
+	case len(evictions) == 0 && cacheLimit > cacheSize:
+		cacheLimit = max(cacheLimit/2, cacheSize)
+		return true
+	}
+	return false
+}
+
+// downloadCtx é o contexto de todos os downloads de capas.
+// CancelDownloads o cancela, abortando downloads em andamento.
+var downloadCtx, cancelDownloads = context.WithCancel(context.Background())
+
+// CancelDownloads aborta os downloads em andamento e faz os próximos
+// falharem imediatamente. Chamado no desligamento do servidor, para que
+// um download lento não segure o encerramento.
+func CancelDownloads() {
+	cancelDownloads()
+}
+
+// httpClient baixa as capas. Usa o transporte padrão, que respeita
+// HTTP_PROXY, HTTPS_PROXY e NO_PROXY; SetProxy fixa um proxy explícito.
+var httpClient = &http.Client{}
+
+// SetProxy faz os downloads de capas passarem pelo proxy em proxyURL,
+// ignorando as variáveis de ambiente. Deve ser chamado antes do primeiro
+// download.
+func SetProxy(proxyURL *url.URL) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	httpClient = &http.Client{Transport: transport}
+}
+
+// FetchImage baixa e decodifica a imagem em url, sem converter para ANSI.
+// Útil para reaproveitar a capa em outros formatos (ex.: PNG). Não usa o
+// cache, que guarda só as renderizações em texto.
+func FetchImage(url string) (image.Image, error) {
+	return fetchImage(url)
+}
+
+// fetchImage baixa e decodifica a imagem em url.
+func fetchImage(url string) (image.Image, error) {
+	return fetchImageTimed(url, nil)
+}
+
+// fetchImageTimed é fetchImage medindo download e decodificação em
+// timings, se não for nil.
+func fetchImageTimed(url string, timings *Timings) (image.Image, error) {
+	// Download image
+	start := timings.now()
+	req, err := http.NewRequestWithContext(downloadCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if timings != nil {
+		timings.Download = time.Since(start)
+	}
+
+	// Decode image
+	start = timings.now()
+	img, err := decode(data)
+	if timings != nil {
+		timings.Decode = time.Since(start)
+	}
+	return img, err
+}
+
+// RenderImage renderiza uma imagem já em memória (ex.: um bitmap gerado)
+// com a mesma técnica de half-blocks de RenderFromURL, sem cache.
+func RenderImage(img image.Image, width, height int, opts Options) string {
+	return renderImage(img, width, height, opts)
 }
 
 // renderImage converte uma imagem em blocos Unicode com cores true color.
 //
 // Formato ANSI true color (24-bit):
-//   \x1b[38;2;R;G;Bm  → define cor de foreground (texto)
-//   \x1b[48;2;R;G;Bm  → define cor de background
-//   ▀                 → caractere half-block (metade superior)
-//   \x1b[0m           → reset para cores padrão
+//
+//	\x1b[38;2;R;G;Bm  → define cor de foreground (texto)
+//	\x1b[48;2;R;G;Bm  → define cor de background
+//	▀                 → caractere half-block (metade superior)
+//	\x1b[0m           → reset para cores padrão
 //
 // O caractere ▀ preenche a metade superior da célula.
 // Combinando foreground (superior) e background (inferior),
 // conseguimos 2 pixels por caractere.
-func renderImage(img image.Image, width, height int) string {
+func renderImage(img image.Image, width, height int, opts Options) string {
+	return renderImageTimed(img, width, height, opts, nil)
+}
+
+// renderImageTimed é renderImage medindo o redimensionamento e a
+// codificação em timings, se não for nil.
+func renderImageTimed(img image.Image, width, height int, opts Options, timings *Timings) string {
 	// Each character represents 2 vertical pixels
 	// So we need width x (height*2) pixels
 	pixelHeight := height * 2
 
-	// Resize image
-	resized := resizeImage(img, width, pixelHeight)
+	// Resize image. Com Density < 1, amostra numa grade menor e repete
+	// cada pixel amostrado em várias células (visual mais "pixelado").
+	sampleW, sampleH := width, pixelHeight
+	if d := opts.Density; d > 0 && d < 1 {
+		sampleW = max(int(float64(width)*d+0.5), 1)
+		sampleH = max(int(float64(pixelHeight)*d+0.5), 1)
+	}
+	bg := opts.Placeholder.BG
+	if opts.Placeholder == (PlaceholderColors{}) {
+		bg = defaultPlaceholder.BG
+	}
+	start := timings.now()
+	defer acquireRender()()
+	if timings != nil {
+		timings.Queue = time.Since(start)
+	}
 
-	var sb strings.Builder
+	start = timings.now()
+	img = fitPortrait(img, width, pixelHeight, opts.Fit, bg)
+	if opts.CellRatio > 0 {
+		img = correctAspect(img, width, height, opts.CellRatio, bg)
+	}
+	resized := resizeImage(img, sampleW, sampleH, opts.Interpolation)
+	if timings != nil {
+		timings.Resize = time.Since(start)
+	}
+	start = timings.now()
+	at := func(x, y int) (uint32, uint32, uint32) {
+		// RGBA() é pré-multiplicado por alpha: basta somar o fundo
+		// na proporção que falta de opacidade
+		r, g, b, a := resized.At(x*sampleW/width, y*sampleH/pixelHeight).RGBA()
+		if a < 0xffff {
+			r += uint32(opts.Background.R) * 0x101 * (0xffff - a) / 0xffff
+			g += uint32(opts.Background.G) * 0x101 * (0xffff - a) / 0xffff
+			b += uint32(opts.Background.B) * 0x101 * (0xffff - a) / 0xffff
+		}
+		r, g, b = r>>8, g>>8, b>>8
+		if opts.Grayscale {
+			r, g, b = luminance(r, g, b)
+		}
+		if t := cornerWeight(x, y, width, pixelHeight, opts.CornerRadius); t > 0 {
+			c := lerpRGBA(color.RGBA{uint8(r), uint8(g), uint8(b), 255}, opts.CornerColor, t)
+			r, g, b = uint32(c.R), uint32(c.G), uint32(c.B)
+		}
+		if opts.Colors > 0 {
+			levels := quantizeLevels(opts.Colors)
+			r, g, b = quantize(r, levels), quantize(g, levels), quantize(b, levels)
+		}
+		return r, g, b
+	}
+
+	w := newCellWriter()
 
 	// Process 2 rows at a time (top pixel = foreground, bottom pixel = background)
 	for y := 0; y < pixelHeight; y += 2 {
 		for x := 0; x < width; x++ {
 			// Top pixel (foreground)
-			topR, topG, topB, _ := resized.At(x, y).RGBA()
-			topR, topG, topB = topR>>8, topG>>8, topB>>8
+			topR, topG, topB := at(x, y)
 
 			// Bottom pixel (background)
 			var botR, botG, botB uint32
 			if y+1 < pixelHeight {
-				botR, botG, botB, _ = resized.At(x, y+1).RGBA()
-				botR, botG, botB = botR>>8, botG>>8, botB>>8
+				botR, botG, botB = at(x, y+1)
 			} else {
 				botR, botG, botB = topR, topG, topB
 			}
 
-			// Write ANSI escape codes with upper half block
 			// Foreground = top pixel, Background = bottom pixel
-			sb.WriteString(fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
-				topR, topG, topB, botR, botG, botB))
+			w.cell(color.RGBA{uint8(topR), uint8(topG), uint8(topB), 255},
+				color.RGBA{uint8(botR), uint8(botG), uint8(botB), 255})
 		}
-		sb.WriteString("\x1b[0m\n") // Reset and newline
+		w.endLine()
 	}
 
-	result := sb.String()
-	// Remove trailing newline
-	if len(result) > 0 && result[len(result)-1] == '\n' {
-		result = result[:len(result)-1]
+	rendered := w.String()
+	if timings != nil {
+		timings.Encode = time.Since(start)
 	}
+	return rendered
+}
 
-	return result
+// cornerWeight retorna o quanto o pixel (x, y) de uma grade w×h está fora
+// dos cantos arredondados de raio radius: 0 dentro, 1 totalmente fora, com
+// um pixel de transição para suavizar o arco.
+func cornerWeight(x, y, w, h, radius int) float64 {
+	radius = min(radius, w/2, h/2)
+	if radius <= 0 {
+		return 0
+	}
+
+	// Distância do centro do pixel ao centro do arco do canto mais próximo
+	cx, cy := float64(radius), float64(radius)
+	px, py := float64(x)+0.5, float64(y)+0.5
+	if x >= w-radius {
+		px = float64(w-x) - 0.5
+	}
+	if y >= h-radius {
+		py = float64(h-y) - 0.5
+	}
+	if px >= cx || py >= cy {
+		return 0
+	}
+
+	d := math.Hypot(cx-px, cy-py)
+	return math.Max(0, math.Min(1, d-float64(radius)+0.5))
 }
 
-// resizeImage redimensiona uma imagem para as dimensões especificadas.
-// Usa interpolação Catmull-Rom para qualidade superior ao nearest-neighbor.
-func resizeImage(img image.Image, width, height int) image.Image {
+// luminance converte uma cor 8-bit para o cinza de mesma luminância (Rec. 601).
+// Retorna o valor repetido nos três canais, prontos para o escape true color.
+func luminance(r, g, b uint32) (uint32, uint32, uint32) {
+	y := (299*r + 587*g + 114*b + 500) / 1000
+	return y, y, y
+}
+
+// resizeImage redimensiona uma imagem para as dimensões especificadas
+// com o algoritmo de interpolação dado.
+func resizeImage(img image.Image, width, height int, interp Interpolation) image.Image {
 	dst := image.NewRGBA(image.Rect(0, 0, width, height))
-	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	interp.scaler().Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
 	return dst
 }
 
-// renderPlaceholder retorna um placeholder cinza quando não há imagem.
-// Usado quando a URL está vazia ou o download falhou.
-func renderPlaceholder(width, height int) string {
-	var sb strings.Builder
-	gray := "\x1b[38;2;60;60;60m\x1b[48;2;40;40;40m▀"
+// ClearCache limpa o cache de imagens.
+// Útil para liberar memória ou forçar re-download.
+// Também volta o limite ao tamanho inicial: sem entradas, o histórico de
+// despejos não diz mais nada sobre a rotatividade.
+func ClearCache() {
+	cacheMu.Lock()
+	cache = make(map[string]cacheEntry)
+	cacheLimit = cacheSize
+	evictions = nil
+	cacheMu.Unlock()
+}
+
+// RenderGenerated renderiza uma arte procedural para músicas sem capa.
+// O gradiente diagonal é derivado do hash de seed, então a mesma música
+// sempre recebe a mesma arte, e músicas diferentes recebem cores diferentes.
+func RenderGenerated(seed string, width, height int, opts Options) string {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	sum := h.Sum32()
+
+	from := hsvToRGB(float64(sum%360), 0.55, 0.80)
+	to := hsvToRGB(float64((sum/360)%360), 0.65, 0.35)
 
-	for y := 0; y < height; y++ {
+	pixelHeight := height * 2
+	img := image.NewRGBA(image.Rect(0, 0, width, pixelHeight))
+	span := float64(width + pixelHeight - 2)
+	for y := 0; y < pixelHeight; y++ {
 		for x := 0; x < width; x++ {
-			sb.WriteString(gray)
+			t := 0.0
+			if span > 0 {
+				t = float64(x+y) / span
+			}
+			img.Set(x, y, lerpRGBA(from, to, t))
 		}
-		sb.WriteString("\x1b[0m\n")
 	}
 
-	result := sb.String()
-	if len(result) > 0 && result[len(result)-1] == '\n' {
-		result = result[:len(result)-1]
-	}
+	return renderImage(img, width, height, opts)
+}
 
-	return result
+// lerpRGBA interpola linearmente entre a e b (t entre 0 e 1).
+func lerpRGBA(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
 }
 
-// ClearCache limpa o cache de imagens.
-// Útil para liberar memória ou forçar re-download.
-func ClearCache() {
-	cacheMu.Lock()
-	cache = make(map[string]cacheEntry)
-	cacheMu.Unlock()
+// hsvToRGB converte matiz (0-360), saturação e valor (0-1) para RGB.
+func hsvToRGB(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8((r+m)*255 + 0.5),
+		G: uint8((g+m)*255 + 0.5),
+		B: uint8((b+m)*255 + 0.5),
+		A: 255,
+	}
 }
@@ -0,0 +1,373 @@
+package albumart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// imageOf monta uma imagem com uma cor por pixel, linha a linha.
+func imageOf(rows ...[]color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+const (
+	fgRed   = "\x1b[38;2;255;0;0m"
+	fgGreen = "\x1b[38;2;0;255;0m"
+	fgBlue  = "\x1b[38;2;0;0;255m"
+	fgWhite = "\x1b[38;2;255;255;255m"
+	bgRed   = "\x1b[48;2;255;0;0m"
+	bgGreen = "\x1b[48;2;0;255;0m"
+	bgBlue  = "\x1b[48;2;0;0;255m"
+	bgWhite = "\x1b[48;2;255;255;255m"
+	reset   = "\x1b[0m"
+)
+
+func TestRenderImage(t *testing.T) {
+	// NearestNeighbor para que o redimensionamento não misture cores
+	opts := Options{Interpolation: NearestNeighbor}
+
+	tests := []struct {
+		name   string
+		img    image.Image
+		height int
+		want   []string
+	}{
+		{
+			name:   "2x2",
+			img:    imageOf([]color.RGBA{red, green}, []color.RGBA{blue, white}),
+			height: 1,
+			want:   []string{fgRed + bgBlue + "▀" + fgGreen + bgWhite + "▀" + reset},
+		},
+		{
+			// 3 linhas de pixels viram 4 (2 linhas de células): o vizinho
+			// mais próximo repete a do meio, lida como 0, 1, 1, 2
+			name:   "2x3",
+			img:    imageOf([]color.RGBA{red, red}, []color.RGBA{green, blue}, []color.RGBA{white, white}),
+			height: 2,
+			want: []string{
+				fgRed + bgGreen + "▀" + bgBlue + "▀" + reset,
+				fgGreen + bgWhite + "▀" + fgBlue + "▀" + reset,
+			},
+		},
+		{
+			// Células iguais não repetem nenhum escape
+			name:   "repeated",
+			img:    imageOf([]color.RGBA{red, red, red}, []color.RGBA{blue, blue, blue}),
+			height: 1,
+			want:   []string{fgRed + bgBlue + "▀▀▀" + reset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderImage(tt.img, tt.img.Bounds().Dx(), tt.height, opts)
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("renderImage =\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+func TestRenderImageLinesAreIndependent(t *testing.T) {
+	// Mesmas cores nas duas linhas: a segunda emite fg e bg de novo
+	img := imageOf([]color.RGBA{red}, []color.RGBA{blue}, []color.RGBA{red}, []color.RGBA{blue})
+	got := renderImage(img, 1, 2, Options{Interpolation: NearestNeighbor})
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), got)
+	}
+	for i, line := range lines {
+		if want := fgRed + bgBlue + "▀" + reset; line != want {
+			t.Errorf("line %d = %q, want %q", i, line, want)
+		}
+	}
+}
+
+// testdata/cmyk.jpg é um JPEG baseline 16×16 em CMYK com o marcador Adobe
+// APP14 e os valores invertidos, como o Photoshop grava, em quatro
+// quadrantes chapados: vermelho, verde (em cima), azul e branco (embaixo).
+func TestRenderCMYKJPEG(t *testing.T) {
+	data, err := os.ReadFile("testdata/cmyk.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := img.(*image.CMYK); !ok {
+		t.Fatalf("decoded as %T, want *image.CMYK", img)
+	}
+
+	// Uma célula por quadrante na horizontal: em cima o fg, embaixo o bg
+	got := renderImage(img, 2, 1, Options{Interpolation: NearestNeighbor})
+	want := fgRed + bgBlue + "▀" + fgGreen + bgWhite + "▀" + reset
+	if got != want {
+		t.Errorf("renderImage =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// gradient é uma imagem com cores diferentes em quase todo pixel, o pior
+// caso para o tamanho da saída.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x + y) * 255 / (w + h)), 255})
+		}
+	}
+	return img
+}
+
+func TestQuantize(t *testing.T) {
+	levels := map[int]int{1: 2, 8: 2, 64: 4, 256: 6}
+	for colors, want := range levels {
+		if got := quantizeLevels(colors); got != want {
+			t.Errorf("quantizeLevels(%d) = %d, want %d", colors, got, want)
+		}
+	}
+
+	// 4 níveis: 0, 85, 170 e 255
+	for v, want := range map[uint32]uint32{0: 0, 42: 0, 43: 85, 100: 85, 128: 170, 212: 170, 213: 255, 255: 255} {
+		if got := quantize(v, 4); got != want {
+			t.Errorf("quantize(%d, 4) = %d, want %d", v, got, want)
+		}
+	}
+}
+
+func TestRenderQuantizedIsSmaller(t *testing.T) {
+	img := gradient(64, 64)
+	full := renderImage(img, 32, 16, Options{})
+	quantized := renderImage(img, 32, 16, Options{Colors: 64})
+
+	if len(quantized) >= len(full)/2 {
+		t.Errorf("64 colors: %d bytes, true color %d; want less than half", len(quantized), len(full))
+	}
+
+	// Mesma grade, só com cores da paleta
+	a, b := parseCells(full), parseCells(quantized)
+	if !sameShape(a, b) {
+		t.Fatal("quantized art has a different shape")
+	}
+	palette := map[uint8]bool{0: true, 85: true, 170: true, 255: true}
+	for _, row := range b {
+		for _, c := range row {
+			for _, v := range []uint8{c.fg.R, c.fg.G, c.fg.B, c.bg.R, c.bg.G, c.bg.B} {
+				if !palette[v] {
+					t.Fatalf("channel value %d outside the 4-level palette", v)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkRenderColors mostra o tamanho da capa do layout completo com e
+// sem quantização, na métrica bytes/art.
+func BenchmarkRenderColors(b *testing.B) {
+	img := gradient(640, 640)
+	for _, colors := range []int{0, 256, 64} {
+		b.Run(fmt.Sprintf("colors=%d", colors), func(b *testing.B) {
+			var out string
+			for b.Loop() {
+				out = renderImage(img, 32, 16, Options{Colors: colors})
+			}
+			b.ReportMetric(float64(len(out)), "bytes/art")
+		})
+	}
+}
+
+// naiveEncode escreve as células sem otimização: fg e bg em toda célula.
+func naiveEncode(grid [][]cell) string {
+	var sb strings.Builder
+	for y, row := range grid {
+		for _, c := range row {
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", c.fg.R, c.fg.G, c.fg.B, c.bg.R, c.bg.G, c.bg.B)
+		}
+		sb.WriteString(reset)
+		if y < len(grid)-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func TestRenderSkipsRepeatedEscapes(t *testing.T) {
+	// Quatro faixas chapadas: muitas células seguidas com as mesmas cores
+	flat := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := range 32 {
+		for x := range 32 {
+			flat.SetRGBA(x, y, []color.RGBA{red, green, blue, white}[x/8])
+		}
+	}
+
+	for name, img := range map[string]image.Image{"flat": flat, "gradient": gradient(32, 32)} {
+		t.Run(name, func(t *testing.T) {
+			got := renderImage(img, 32, 16, Options{Interpolation: NearestNeighbor})
+			cells := parseCells(got)
+			naive := naiveEncode(cells)
+
+			if !reflect.DeepEqual(parseCells(naive), cells) {
+				t.Fatal("optimized output does not show the same cells")
+			}
+			if len(got) > len(naive) {
+				t.Errorf("optimized output is %d bytes, naive %d", len(got), len(naive))
+			}
+			if name == "flat" && len(got) >= len(naive)/4 {
+				t.Errorf("flat art is %d bytes, naive %d; want under a quarter", len(got), len(naive))
+			}
+		})
+	}
+}
+
+func TestRenderGrayscale(t *testing.T) {
+	img := imageOf(
+		[]color.RGBA{red, green, blue, white},
+		[]color.RGBA{blue, white, red, green},
+	)
+	gray := renderImage(img, 4, 1, Options{Interpolation: NearestNeighbor, Grayscale: true})
+
+	escapes := 0
+	for _, m := range cellToken.FindAllStringSubmatch(gray, -1) {
+		if m[1] == "" {
+			continue
+		}
+		escapes++
+		if m[2] != m[3] || m[3] != m[4] {
+			t.Errorf("escape %q is not gray", m[0])
+		}
+	}
+	if escapes == 0 {
+		t.Fatalf("no truecolor escapes in %q", gray)
+	}
+
+	// Sem a opção, a mesma imagem sai colorida
+	if colored := renderImage(img, 4, 1, Options{Interpolation: NearestNeighbor}); !strings.Contains(colored, fgRed) {
+		t.Errorf("render without Grayscale lost its colors: %q", colored)
+	}
+}
+
+// distinctColors conta as cores diferentes (fg e bg) numa saída renderizada.
+func distinctColors(rendered string) int {
+	seen := map[color.RGBA]bool{}
+	for _, line := range parseCells(rendered) {
+		for _, c := range line {
+			seen[c.fg], seen[c.bg] = true, true
+		}
+	}
+	return len(seen)
+}
+
+func TestRenderDensity(t *testing.T) {
+	img := gradient(32, 32)
+	render := func(density float64) string {
+		return renderImage(img, 32, 16, Options{Interpolation: NearestNeighbor, Density: density})
+	}
+
+	full, half := render(1), render(0.5)
+	if got, want := distinctColors(half), distinctColors(full); got >= want {
+		t.Errorf("density 0.5 has %d colors, density 1 has %d; want fewer", got, want)
+	}
+	if render(0) != full {
+		t.Error("density 0 differs from density 1")
+	}
+	if lines := strings.Split(half, "\n"); len(lines) != 16 || len(parseCells(half)[0]) != 32 {
+		t.Errorf("density 0.5 changed the size: %d lines", len(lines))
+	}
+}
+
+func TestParseInterpolation(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Interpolation
+		wantOK bool
+	}{
+		{"catmullrom", CatmullRom, true},
+		{"bilinear", Bilinear, true},
+		{"nearest", NearestNeighbor, true},
+		{"lanczos", CatmullRom, false},
+		{"Nearest", CatmullRom, false},
+		{"", CatmullRom, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseInterpolation(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseInterpolation(%q) = %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRenderInterpolationDiffers(t *testing.T) {
+	// Ampliando um xadrez, o vizinho mais próximo mantém as cores puras
+	// e o Catmull-Rom mistura as vizinhas
+	img := imageOf([]color.RGBA{red, blue}, []color.RGBA{blue, red})
+	nearest := renderImage(img, 8, 4, Options{Interpolation: NearestNeighbor})
+	smooth := renderImage(img, 8, 4, Options{Interpolation: CatmullRom})
+
+	if nearest == smooth {
+		t.Fatal("nearest and catmullrom rendered the same")
+	}
+	if n := distinctColors(nearest); n != 2 {
+		t.Errorf("nearest has %d colors, want 2", n)
+	}
+	if n := distinctColors(smooth); n <= 2 {
+		t.Errorf("catmullrom has %d colors, want blended ones", n)
+	}
+}
+
+func TestRenderBlendsTransparency(t *testing.T) {
+	// PNG com a coluna da esquerda vermelha meio transparente e a da
+	// direita vermelha opaca
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for y := range 2 {
+		src.SetNRGBA(0, y, color.NRGBA{255, 0, 0, 128})
+		src.SetNRGBA(1, y, color.NRGBA{255, 0, 0, 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bg := range []color.RGBA{blue, white} {
+		cells := parseCells(renderImage(img, 2, 1, Options{Interpolation: NearestNeighbor, Background: bg}))
+		half := color.RGBA{
+			uint8((255*128 + int(bg.R)*127) / 255),
+			uint8(int(bg.G) * 127 / 255),
+			uint8(int(bg.B) * 127 / 255),
+			255,
+		}
+		if got := cells[0][0]; !near(got.fg, half, 1) || !near(got.bg, half, 1) {
+			t.Errorf("background %v: translucent cell = %v, want %v", bg, got, half)
+		}
+		if got := cells[0][1]; got.fg != red || got.bg != red {
+			t.Errorf("background %v: opaque cell = %v, want red", bg, got)
+		}
+	}
+}
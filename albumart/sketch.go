@@ -0,0 +1,124 @@
+package albumart
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// sketchThreshold é a magnitude mínima do gradiente (luminância 0-255)
+// para que um pixel conte como borda.
+const sketchThreshold = 96
+
+// RenderSketchFromURL renderiza a capa como um "esboço" ASCII: as bordas
+// da imagem viram traços (- / | \ +) e o resto fica em branco.
+// Um caractere por célula, sem cores; o estilo fica a cargo de quem chama.
+//
+// Em caso de URL vazia, imagem pequena demais (ver minSourceSize) ou
+// erro, retorna o esboço da imagem de fallback (ver SetFallbackImage) ou,
+// sem ela, o placeholder correspondente (ver RenderSketchPlaceholder).
+func RenderSketchFromURL(url string, width, height int) (string, error) {
+	if url == "" {
+		return renderSketchFallback(PlaceholderMissing, width, height), nil
+	}
+
+	key := fmt.Sprintf("%s|%dx%d|sketch", url, width, height)
+
+	rendered, err := renderCached(key, url, nil, func(img image.Image) string {
+		if degenerate(img) {
+			return renderSketchFallback(PlaceholderMissing, width, height)
+		}
+		return renderSketch(img, width, height)
+	})
+	if err != nil {
+		return renderSketchFallback(PlaceholderFailed, width, height), err
+	}
+
+	return rendered, nil
+}
+
+// renderSketch aplica o operador de Sobel na luminância da imagem reduzida
+// e converte a orientação de cada borda em um caractere:
+//
+//   - borda horizontal      |  borda vertical
+//     /  diagonal ascendente   \  diagonal descendente
+//   - cruzamento (bordas nos vizinhos horizontais e verticais)
+func renderSketch(img image.Image, width, height int) string {
+	defer acquireRender()()
+	resized := resizeImage(img, width, height, CatmullRom)
+
+	lum := make([][]float64, height)
+	for y := range lum {
+		lum[y] = make([]float64, width)
+		for x := range lum[y] {
+			r, g, b, _ := resized.At(x, y).RGBA()
+			l, _, _ := luminance(r>>8, g>>8, b>>8)
+			lum[y][x] = float64(l)
+		}
+	}
+
+	// at repete os pixels da borda para o kernel não sair da imagem
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), width-1)
+		y = min(max(y, 0), height-1)
+		return lum[y][x]
+	}
+
+	chars := make([][]byte, height)
+	for y := 0; y < height; y++ {
+		chars[y] = make([]byte, width)
+		for x := 0; x < width; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) -
+				at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+
+			chars[y][x] = edgeChar(gx, gy)
+		}
+	}
+
+	isEdge := func(x, y int) bool {
+		return x >= 0 && x < width && y >= 0 && y < height && chars[y][x] != ' '
+	}
+
+	var sb strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := chars[y][x]
+			if c != ' ' && isEdge(x-1, y) && isEdge(x+1, y) && isEdge(x, y-1) && isEdge(x, y+1) &&
+				chars[y][x-1] != chars[y-1][x] {
+				c = '+'
+			}
+			sb.WriteByte(c)
+		}
+		if y < height-1 {
+			sb.WriteByte('\n')
+		}
+	}
+
+	return sb.String()
+}
+
+// edgeChar escolhe o caractere para um gradiente (gx, gy).
+// A borda é perpendicular ao gradiente, então um gradiente horizontal
+// (gx forte) desenha uma borda vertical.
+func edgeChar(gx, gy float64) byte {
+	if math.Hypot(gx, gy) < sketchThreshold {
+		return ' '
+	}
+
+	// Ângulo da borda em [0, 180), com y crescendo para baixo
+	angle := math.Mod(math.Atan2(gy, gx)*180/math.Pi+90+360, 180)
+
+	switch {
+	case angle < 22.5 || angle >= 157.5:
+		return '-'
+	case angle < 67.5:
+		return '\\'
+	case angle < 112.5:
+		return '|'
+	default:
+		return '/'
+	}
+}
@@ -0,0 +1,40 @@
+package albumart
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestRenderSketchVerticalEdge(t *testing.T) {
+	// Metade esquerda preta, metade direita branca: a única borda é a
+	// vertical entre as colunas 7 e 8
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := range 16 {
+		for x := range 16 {
+			c := color.RGBA{0, 0, 0, 255}
+			if x >= 8 {
+				c = white
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	got := renderSketch(img, 16, 8)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want 8:\n%s", len(lines), got)
+	}
+	for y, line := range lines {
+		for x, c := range line {
+			boundary := x == 7 || x == 8
+			switch {
+			case boundary && c != '|':
+				t.Errorf("line %d, column %d = %q, want '|'", y, x, c)
+			case !boundary && c != ' ':
+				t.Errorf("line %d, column %d = %q, want blank off the boundary", y, x, c)
+			}
+		}
+	}
+}
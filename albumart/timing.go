@@ -0,0 +1,64 @@
+package albumart
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timings são as durações de cada fase de uma renderização a partir de
+// uma URL. Renderizações servidas pelo cache não são medidas.
+type Timings struct {
+	Download time.Duration // Requisição HTTP e leitura do corpo
+	Decode   time.Duration // Decodificação do JPEG/PNG
+	Queue    time.Duration // Espera por uma vaga de renderização (ver SetRenderWorkers)
+	Resize   time.Duration // Enquadramento e redimensionamento para a grade de pixels
+	Encode   time.Duration // Conversão dos pixels em half-blocks com escapes ANSI
+}
+
+// Total é a soma das fases.
+func (t Timings) Total() time.Duration {
+	return t.Download + t.Decode + t.Queue + t.Resize + t.Encode
+}
+
+// timingsHook recebe as medições de cada renderização; nil desliga a
+// medição, e o pipeline não chama time.Now em nenhuma fase.
+var timingsHook atomic.Pointer[func(url string, t Timings)]
+
+// SetTimingsHook registra fn para receber as durações das fases de cada
+// renderização de RenderFromURL e RenderFromURLWithOptions que não veio
+// do cache. fn roda na goroutine que renderizou; nil desliga a medição.
+func SetTimingsHook(fn func(url string, t Timings)) {
+	if fn == nil {
+		timingsHook.Store(nil)
+		return
+	}
+	timingsHook.Store(&fn)
+}
+
+// newTimings retorna onde medir a próxima renderização, ou nil se a
+// medição está desligada.
+func newTimings() *Timings {
+	if timingsHook.Load() == nil {
+		return nil
+	}
+	return &Timings{}
+}
+
+// report entrega t ao hook, se a medição estava ligada.
+func (t *Timings) report(url string) {
+	if t == nil {
+		return
+	}
+	if fn := timingsHook.Load(); fn != nil {
+		(*fn)(url, *t)
+	}
+}
+
+// now retorna o instante atual, ou zero se t é nil: sem medição, nem o
+// relógio é consultado.
+func (t *Timings) now() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
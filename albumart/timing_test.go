@@ -0,0 +1,62 @@
+package albumart
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTimingsHook(t *testing.T) {
+	srv, _ := coverServer(t)
+
+	var (
+		mu    sync.Mutex
+		calls []Timings
+	)
+	SetTimingsHook(func(url string, tm Timings) {
+		if url != srv.URL+"/timed" {
+			t.Errorf("hook got url %q", url)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, tm)
+	})
+	t.Cleanup(func() { SetTimingsHook(nil) })
+
+	for range 2 {
+		if _, err := RenderFromURL(srv.URL+"/timed", 8, 4); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 {
+		t.Fatalf("hook called %d times, want 1: cache hits are not measured", len(calls))
+	}
+	tm := calls[0]
+	for name, d := range map[string]int64{
+		"download": int64(tm.Download),
+		"decode":   int64(tm.Decode),
+		"resize":   int64(tm.Resize),
+		"encode":   int64(tm.Encode),
+	} {
+		if d <= 0 {
+			t.Errorf("%s = %d, want > 0", name, d)
+		}
+	}
+	if tm.Total() != tm.Download+tm.Decode+tm.Queue+tm.Resize+tm.Encode {
+		t.Errorf("Total = %v, want the sum of the phases", tm.Total())
+	}
+}
+
+func TestTimingsDisabled(t *testing.T) {
+	SetTimingsHook(nil)
+	if tm := newTimings(); tm != nil {
+		t.Errorf("newTimings = %+v without a hook, want nil", tm)
+	}
+	var tm *Timings
+	tm.report("url") // Não pode entrar em pânico
+	if !tm.now().IsZero() {
+		t.Error("nil Timings read the clock")
+	}
+}
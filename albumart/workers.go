@@ -0,0 +1,34 @@
+package albumart
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// renderSlots limita quantas renderizações (redimensionamento e
+// codificação ANSI) rodam ao mesmo tempo. Com muitas sessões trocando de
+// música juntas, cada uma renderizando na sua goroutine, o Scale do
+// CatmullRom disputaria todos os núcleos e a latência de todas subiria;
+// com o limite, as excedentes esperam na fila e as demais terminam no
+// tempo normal. Os downloads não ocupam vaga: esperam a rede, não a CPU.
+var renderSlots atomic.Pointer[chan struct{}]
+
+func init() {
+	SetRenderWorkers(runtime.GOMAXPROCS(0))
+}
+
+// SetRenderWorkers define quantas renderizações podem rodar ao mesmo
+// tempo; n < 1 vira 1. Renderizações em andamento terminam na vaga que
+// já ocupam.
+func SetRenderWorkers(n int) {
+	slots := make(chan struct{}, max(n, 1))
+	renderSlots.Store(&slots)
+}
+
+// acquireRender espera uma vaga de renderização e retorna a função que a
+// libera.
+func acquireRender() (release func()) {
+	slots := *renderSlots.Load()
+	slots <- struct{}{}
+	return func() { <-slots }
+}
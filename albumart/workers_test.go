@@ -0,0 +1,156 @@
+package albumart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withRenderWorkers define n vagas durante o teste e restaura o padrão no fim.
+func withRenderWorkers(tb testing.TB, n int) {
+	tb.Helper()
+	SetRenderWorkers(n)
+	tb.Cleanup(func() { SetRenderWorkers(runtime.GOMAXPROCS(0)) })
+}
+
+// peakTracker mede quantas vagas estiveram ocupadas ao mesmo tempo.
+type peakTracker struct {
+	cur, peak atomic.Int32
+}
+
+func (p *peakTracker) enter() {
+	n := p.cur.Add(1)
+	for {
+		old := p.peak.Load()
+		if n <= old || p.peak.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+func (p *peakTracker) leave() { p.cur.Add(-1) }
+
+func TestRenderWorkersLimit(t *testing.T) {
+	for _, workers := range []int{1, 2, 4} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			withRenderWorkers(t, workers)
+
+			var p peakTracker
+			var wg sync.WaitGroup
+			for range 32 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					release := acquireRender()
+					p.enter()
+					time.Sleep(time.Millisecond)
+					p.leave()
+					release()
+				}()
+			}
+			wg.Wait()
+
+			if peak := p.peak.Load(); peak > int32(workers) {
+				t.Errorf("%d renders at once, limit %d", peak, workers)
+			}
+		})
+	}
+}
+
+func TestSetRenderWorkersNonPositive(t *testing.T) {
+	withRenderWorkers(t, 0)
+	if n := cap(*renderSlots.Load()); n != 1 {
+		t.Errorf("SetRenderWorkers(0) gave %d slots, want 1", n)
+	}
+}
+
+// TestSetRenderWorkersWhileRendering troca o limite enquanto renderizações
+// reais ocupam vagas. Cada uma devolve a vaga ao canal de onde a tirou,
+// então nenhuma fica presa e, depois da última troca, vale o novo limite.
+// Rode com -race.
+func TestSetRenderWorkersWhileRendering(t *testing.T) {
+	withRenderWorkers(t, 2)
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				renderImage(img, 8, 4, Options{})
+				renderSketch(img, 8, 4)
+			}
+		}()
+	}
+	for i := range 50 {
+		SetRenderWorkers(i%4 + 1)
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("renders stuck after swapping the worker limit")
+	}
+
+	// Depois da troca, o limite novo vale para todas as vagas
+	SetRenderWorkers(1)
+	release := acquireRender()
+	acquired := make(chan struct{})
+	go func() {
+		acquireRender()()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second render got a slot with a limit of 1")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	<-acquired
+}
+
+// BenchmarkRenderConcurrent renderiza uma capa 640×640 em muitas
+// goroutines ao mesmo tempo, como várias sessões trocando de música
+// juntas, com limites de vagas diferentes.
+func BenchmarkRenderConcurrent(b *testing.B) {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 640))
+	for y := range 640 {
+		for x := range 640 {
+			img.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), uint8(x + y), 255})
+		}
+	}
+
+	procs := runtime.GOMAXPROCS(0)
+	for _, workers := range slices.Compact([]int{1, procs, 4 * procs}) {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			withRenderWorkers(b, workers)
+			b.SetParallelism(4)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					renderImage(img, 32, 16, Options{}) // A capa do layout completo
+				}
+			})
+		})
+	}
+}
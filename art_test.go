@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"ssh-portfolio/albumart"
+	"ssh-portfolio/spotify"
+)
+
+func TestRenderArtSessionSeed(t *testing.T) {
+	track := &spotify.Track{Name: "Sem Capa", Album: "Demo"}
+	render := func(seed string) string {
+		t.Helper()
+		art, err := renderArt(track, artBlocks, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return art
+	}
+
+	// Sem seed, a arte depende só da música
+	if render("") != render("") {
+		t.Error("generated art without a seed is not deterministic")
+	}
+	if render("sessao1") != render("sessao1") {
+		t.Error("the same seed produced different art")
+	}
+	if render("sessao1") == render("sessao2") {
+		t.Error("different seeds produced the same art")
+	}
+	if render("sessao1") == render("") {
+		t.Error("a seed did not change the default art")
+	}
+}
+
+func TestRenderArtWithoutImages(t *testing.T) {
+	client := fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/token" {
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		w.Write([]byte(`{"items":[{"played_at":"2024-01-01T12:00:00Z","track":{"name":"Faixa Local",` +
+			`"album":{"name":"","images":[]},"artists":[{"name":"Artista"}]}}]}`))
+	}))
+	track, err := client.GetRecentlyPlayed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No esboço, a capa ausente vira o placeholder
+	art, err := renderArt(track, artSketch, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := styles().artist.Render(albumart.RenderSketchPlaceholder(albumart.PlaceholderMissing, artWidth, artHeight))
+	if art != want {
+		t.Errorf("sketch art =\n%s\nwant the missing placeholder\n%s", art, want)
+	}
+
+	// Nos blocos, sem imagem de fallback, vira a arte gerada
+	art, err = renderArt(track, artBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := artOptions
+	opts.Placeholder = styles().theme.placeholder()
+	if art == "" || art == albumart.RenderPlaceholder(albumart.PlaceholderMissing, artWidth, artHeight, opts) {
+		t.Errorf("block art = %q, want the generated art", art)
+	}
+}
+
+func TestRenderArtCollageFallback(t *testing.T) {
+	prevCollage, prevFallback, prevClient := artistCollage, artFallback, spotifyClient
+	t.Cleanup(func() { artistCollage, artFallback, spotifyClient = prevCollage, prevFallback, prevClient })
+	artistCollage = true
+	// Sem imagem de fallback carregada, o fallback da capa é o placeholder
+	artFallback = true
+
+	photos, _ := artServer(t, nil)
+	spotifyClient = fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/token" {
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		// A segunda foto não existe: a colagem falha
+		fmt.Fprintf(w, `{"artists":[{"id":"a","images":[{"url":%q,"width":16,"height":16}]},`+
+			`{"id":"b","images":[{"url":%q,"width":16,"height":16}]}]}`,
+			photos.URL+"/a.png", "http://127.0.0.1:1/b.png")
+	}))
+
+	track := &spotify.Track{Name: "Dueto", ArtistIDs: []string{"a", "b"}}
+	art, err := renderArt(track, artBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := artOptions
+	opts.Placeholder = styles().theme.placeholder()
+	if want := albumart.RenderPlaceholder(albumart.PlaceholderMissing, artWidth, artHeight, opts); art != want {
+		t.Errorf("art after a failed collage =\n%s\nwant the placeholder\n%s", art, want)
+	}
+}
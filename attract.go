@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// attractIdle é quanto tempo sem teclas até o modo atração começar.
+const attractIdle = time.Minute
+
+// attractInterval é o tempo de cada música no modo atração (ATTRACT_INTERVAL).
+// Zero desliga o modo.
+var attractInterval time.Duration
+
+// attracting informa se a sessão está no modo atração: ligado, nada
+// tocando (só o fallback do histórico), ninguém mexendo há attractIdle e
+// histórico suficiente para alternar.
+func (m model) attracting() bool {
+	return attractInterval > 0 &&
+		m.currentTrack != nil &&
+		playbackStateOf(m.currentTrack) == stateRecent &&
+		len(m.history) > 1 &&
+		!m.showRecent &&
+		time.Since(m.lastInput) >= attractIdle
+}
+
+// shownTrack retorna a música exibida: a atual ou, no modo atração, a
+// música da vez no histórico, da mais recente para a mais antiga.
+func (m model) shownTrack() *spotify.Track {
+	if !m.attracting() {
+		return m.currentTrack
+	}
+	return attractTrack(m.history, m.attractIndex)
+}
+
+// attractTrack retorna a música de índice i (circular) de history,
+// contando a partir da mais recente. nil se history está vazio.
+func attractTrack(history []*spotify.Track, i int) *spotify.Track {
+	n := len(history)
+	if n == 0 {
+		return nil
+	}
+	return history[n-1-i%n]
+}
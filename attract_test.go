@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"ssh-portfolio/spotify"
+)
+
+func TestAttractTrack(t *testing.T) {
+	// Da mais antiga à mais recente, como o provider entrega
+	history := []*spotify.Track{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+
+	for i, want := range []string{"C", "B", "A", "C", "B", "A", "C"} {
+		if got := attractTrack(history, i); got.Name != want {
+			t.Errorf("attractTrack(history, %d) = %s, want %s", i, got.Name, want)
+		}
+	}
+
+	if got := attractTrack(nil, 0); got != nil {
+		t.Errorf("attractTrack(nil, 0) = %v, want nil", got)
+	}
+	if got := attractTrack([]*spotify.Track{}, 3); got != nil {
+		t.Errorf("attractTrack(empty, 3) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xo/terminfo"
+)
+
+// termCaps são as capacidades do terminal de uma sessão.
+type termCaps struct {
+	term      string // TERM informado pelo cliente no pedido de pty
+	known     bool   // TERM encontrado na base terminfo do servidor
+	colors    int    // max_colors do terminfo
+	truecolor bool   // Cores 24-bit (capability estendida Tc ou RGB)
+	sixel     bool   // Gráficos sixel
+	unicode   bool   // Locale UTF-8, necessário para half-blocks e ❚❚
+	altScreen bool   // Tela alternativa (smcup/rmcup)
+}
+
+// detectCaps consulta o terminfo de term e o locale em environ.
+//
+// Sem o terminfo (TERM desconhecido ou base ausente no servidor) assume o
+// mínimo: 8 cores, sem truecolor nem sixel. A tela alternativa é a exceção:
+// quase todo terminal a suporta, e a base do servidor costuma não ter os
+// TERMs mais novos (xterm-kitty, alacritty...), então só um terminfo sem
+// smcup, ou TERM=dumb, a desliga.
+func detectCaps(term string, environ []string) termCaps {
+	caps := termCaps{term: term, colors: 8, unicode: utf8Locale(environ), altScreen: term != "dumb"}
+
+	ti, err := terminfo.Load(term)
+	if err != nil {
+		return caps
+	}
+	caps.known = true
+
+	if n, ok := ti.Nums[terminfo.MaxColors]; ok {
+		caps.colors = n
+	}
+	ext := ti.ExtBoolCapsShort()
+	caps.truecolor = ext["Tc"] || ext["RGB"] || caps.colors >= 1<<24
+	// Não há capability padrão para sixel; o xterm e derivados usam Sxl
+	caps.sixel = ext["Sxl"] || strings.Contains(term, "sixel")
+	caps.altScreen = len(ti.Strings[terminfo.EnterCaMode]) > 0
+
+	return caps
+}
+
+// utf8Locale informa se o locale em environ (LC_ALL, LC_CTYPE ou LANG,
+// nessa ordem) é UTF-8. Sem locale informado, assume UTF-8: clientes SSH
+// raramente repassam essas variáveis, e quase todo terminal atual é UTF-8.
+func utf8Locale(environ []string) bool {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	for _, k := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := env[k]; v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return true
+}
+
+// artMode escolhe o melhor modo de arte para o terminal.
+//
+// Os half-blocks usam escapes 24-bit que não são convertidos para 256
+// cores; terminais com 256 cores quase sempre aceitam truecolor mesmo sem
+// anunciar Tc, então também recebem blocks. Abaixo disso, o esboço ASCII.
+func (c termCaps) artMode() artMode {
+	if c.unicode && (c.truecolor || c.colors >= 256) {
+		return artBlocks
+	}
+	return artSketch
+}
+
+// String resume as capacidades para o rodapé de debug.
+func (c termCaps) String() string {
+	if c.term == "" {
+		return "term: ?"
+	}
+	s := fmt.Sprintf("term: %s %dc", c.term, c.colors)
+	if !c.known {
+		s += " (sem terminfo)"
+	}
+	if c.truecolor {
+		s += " truecolor"
+	}
+	if c.sixel {
+		s += " sixel"
+	}
+	if !c.unicode {
+		s += " sem-utf8"
+	}
+	if !c.altScreen {
+		s += " sem-altscreen"
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"sync"
+	"time"
+
+	"ssh-portfolio/albumart"
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Dimensões do card PNG, em pixels.
+const (
+	cardWidth   = 800
+	cardHeight  = 320
+	cardPadding = 32
+	cardArtSize = cardHeight - 2*cardPadding
+)
+
+// cardFonts são as fontes do card, carregadas uma vez (Go fonts embutidas).
+var cardFonts = func() (f struct{ title, artist, album font.Face }) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		panic(err)
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		panic(err)
+	}
+	face := func(fnt *opentype.Font, size float64) font.Face {
+		face, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			panic(err)
+		}
+		return face
+	}
+	f.title = face(bold, 34)
+	f.artist = face(regular, 26)
+	f.album = face(regular, 20)
+	return f
+}()
+
+// renderCard compõe o card "tocando agora" (capa + texto) como imagem,
+// nas cores do tema. Sem música, mostra um card padrão; sem art, o
+// quadrado da capa fica na cor do placeholder.
+//
+// Usa a capa decodificada, não a versão ANSI, então o card tem a
+// resolução real da imagem.
+func renderCard(track *spotify.Track, art image.Image, t Theme) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(hexRGBA(t.Background)), image.Point{}, draw.Src)
+
+	artRect := image.Rect(cardPadding, cardPadding, cardPadding+cardArtSize, cardPadding+cardArtSize)
+	draw.Draw(img, artRect, image.NewUniform(t.PlaceholderBG), image.Point{}, draw.Src)
+
+	textX := artRect.Max.X + cardPadding
+	textWidth := cardWidth - textX - cardPadding
+
+	if track == nil {
+		drawText(img, cardFonts.artist, hexRGBA(t.Muted), textX, cardHeight/2, textWidth, "Nenhuma música")
+		return img
+	}
+
+	if art != nil {
+		draw.CatmullRom.Scale(img, artRect, art, art.Bounds(), draw.Src, nil)
+	}
+
+	y := cardPadding + 90
+	drawText(img, cardFonts.title, hexRGBA(t.Text), textX, y, textWidth, cmp.Or(track.Name, "Música desconhecida"))
+	y += 48
+	drawText(img, cardFonts.artist, hexRGBA(t.Secondary), textX, y, textWidth, cmp.Or(track.Artist, "Artista desconhecido"))
+	y += 38
+	drawText(img, cardFonts.album, hexRGBA(t.Muted), textX, y, textWidth, track.Album)
+
+	return img
+}
+
+// drawText escreve s com a linha de base em (x, y), cortando com "..."
+// para caber em maxWidth pixels.
+func drawText(img *image.RGBA, face font.Face, c color.Color, x, y, maxWidth int, s string) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+
+	limit := fixed.I(maxWidth)
+	if d.MeasureString(s) > limit {
+		runes := []rune(s)
+		for len(runes) > 0 && d.MeasureString(string(runes)+"...") > limit {
+			runes = runes[:len(runes)-1]
+		}
+		s = string(runes) + "..."
+	}
+	d.DrawString(s)
+}
+
+// cardRetryInterval é por quanto tempo um card sem capa (download falhou)
+// é servido antes de tentar baixá-la de novo.
+const cardRetryInterval = 30 * time.Second
+
+// cardCache guarda o último card codificado. A rota é pública e o card só
+// muda com a música ou o tema: sem o cache, cada GET baixaria a capa de
+// novo e recodificaria o PNG. O mutex fica travado durante a renderização,
+// então pedidos simultâneos de um card novo geram um único download.
+var cardCache struct {
+	sync.Mutex
+	key     string
+	png     []byte
+	expires time.Time // Zero para cards completos, que valem até a música mudar
+}
+
+// cardPNG retorna o card de track no tema de s, codificado em PNG.
+func cardPNG(track *spotify.Track, s *themeStyles) ([]byte, error) {
+	key := fmt.Sprint(s.gen)
+	if track != nil {
+		key += "\x00" + artKey(track) + "\x00" + track.Artist
+	}
+
+	cardCache.Lock()
+	defer cardCache.Unlock()
+
+	if cardCache.png != nil && cardCache.key == key &&
+		(cardCache.expires.IsZero() || time.Now().Before(cardCache.expires)) {
+		return cardCache.png, nil
+	}
+
+	var (
+		art     image.Image
+		expires time.Time
+	)
+	if track != nil && track.ArtworkURL != "" {
+		var err error
+		if art, err = albumart.FetchImage(track.ArtworkURL); err != nil {
+			log.Debug("Falha ao baixar capa para o card", "url", track.ArtworkURL, "error", err)
+			expires = time.Now().Add(cardRetryInterval)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderCard(track, art, s.theme)); err != nil {
+		return nil, err
+	}
+	cardCache.key, cardCache.png, cardCache.expires = key, buf.Bytes(), expires
+	return cardCache.png, nil
+}
+
+// handleCard serve o card da música atual como PNG.
+func handleCard(p *Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := cardPNG(p.Current().track, styles())
+		if err != nil {
+			log.Debug("Falha ao gerar card", "error", err)
+			http.Error(w, "falha ao gerar card", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(data)
+	}
+}
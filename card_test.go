@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// artServer serve uma capa PNG e conta os downloads. Com fail, responde 500.
+func artServer(t *testing.T, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if fail != nil && fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// resetCardCache esvazia o cache do card antes e depois do teste.
+func resetCardCache(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		cardCache.Lock()
+		cardCache.key, cardCache.png, cardCache.expires = "", nil, time.Time{}
+		cardCache.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// getCard pede o card a handler e confere que a resposta é um PNG.
+func getCard(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/now-playing.png", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if _, err := png.Decode(rec.Body); err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+}
+
+func TestCardCachedPerTrack(t *testing.T) {
+	resetCardCache(t)
+	srv, hits := artServer(t, nil)
+	p := newTestProvider()
+	handler := handleCard(p)
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One", ArtworkURL: srv.URL + "/one"}})
+	for range 3 {
+		getCard(t, handler)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d downloads for the same track, want 1", n)
+	}
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "Two", ArtworkURL: srv.URL + "/two"}})
+	getCard(t, handler)
+	getCard(t, handler)
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("%d downloads after the track changed, want 2", n)
+	}
+}
+
+func TestCardRetriesFailedArt(t *testing.T) {
+	resetCardCache(t)
+	var fail atomic.Bool
+	fail.Store(true)
+	srv, hits := artServer(t, &fail)
+	p := newTestProvider()
+	handler := handleCard(p)
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One", ArtworkURL: srv.URL}})
+	getCard(t, handler)
+	getCard(t, handler)
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d downloads within the retry interval, want 1", n)
+	}
+
+	// Passado o intervalo, a capa é tentada de novo
+	cardCache.Lock()
+	cardCache.expires = time.Now().Add(-time.Second)
+	cardCache.Unlock()
+	fail.Store(false)
+	getCard(t, handler)
+	getCard(t, handler)
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("%d downloads after the retry interval, want 2", n)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// noticeMsg troca o aviso do rodapé; vem de Cmds que terminam depois da tecla.
+type noticeMsg string
+
+// Quanto tempo um aviso fica no rodapé. O link da tecla o fica mais, para
+// dar tempo de copiá-lo à mão nos terminais sem OSC 52.
+const (
+	noticeDuration     = 4 * time.Second
+	linkNoticeDuration = 15 * time.Second
+)
+
+// showNotice mostra text no rodapé por d. Um aviso novo substitui o
+// anterior e recomeça a contagem.
+func (m model) showNotice(text string, d time.Duration) (model, tea.Cmd) {
+	m.notice = text
+	return m, m.tickers.start(tickerNotice, d)
+}
+
+// playerControl é um controle do player: a tecla, o glifo mostrado na
+// linha de controles e a ação correspondente em Track.Disallows.
+type playerControl struct {
+	key    string
+	glyph  string
+	action string
+}
+
+// control retorna o controle de key. Espaço alterna entre pausar e
+// retomar conforme o estado da música atual.
+func (m model) control(key string) (playerControl, bool) {
+	switch key {
+	case " ":
+		if m.currentTrack != nil && m.currentTrack.IsPlaying {
+			return playerControl{"espaço", "⏸", spotify.ActionPausing}, true
+		}
+		return playerControl{"espaço", "▶", spotify.ActionResuming}, true
+	case ".":
+		return playerControl{".", "⏭", spotify.ActionSkippingNext}, true
+	case ",":
+		return playerControl{",", "⏮", spotify.ActionSkippingPrev}, true
+	}
+	return playerControl{}, false
+}
+
+// allowed informa se o Spotify permite c na música atual. Sem música,
+// nada é bloqueado: o comando vai para a API e ela decide.
+func (m model) allowed(c playerControl) bool {
+	return m.currentTrack == nil || m.currentTrack.Allows(c.action)
+}
+
+// controlsLine mostra os controles do player para o dono, com os que o
+// Spotify não permite agora apagados e riscados. Vazio para visitantes.
+func (m model) controlsLine() string {
+	if !m.owner || spotifyClient == nil || provider == nil || m.currentTrack == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, key := range []string{",", " ", "."} {
+		c, _ := m.control(key)
+		style := styles().artist
+		if !m.allowed(c) {
+			style = styles().footer.Faint(true).Strikethrough(true)
+		}
+		parts = append(parts, style.Render(c.glyph+" "+c.key))
+	}
+	return strings.Join(parts, styles().footer.Render("  "))
+}
+
+// playbackControl trata as teclas de controle do player, só do dono:
+//
+//	espaço  → pausa ou retoma
+//	.       → próxima música
+//	,       → música anterior
+//
+// O comando vai direto para a API e o provider é acordado para buscar o
+// novo estado; ações que o Spotify marcou como indisponíveis só geram um
+// aviso. Retorna ok false se key não é uma tecla de controle ou a
+// sessão não pode controlar o player; visitantes só veem o widget.
+func (m model) playbackControl(key string) (tea.Cmd, bool) {
+	if !m.owner || spotifyClient == nil || provider == nil {
+		return nil, false
+	}
+
+	var (
+		action func() error
+		label  string
+	)
+	control, ok := m.control(key)
+	if !ok {
+		return nil, false
+	}
+	if !m.allowed(control) {
+		// O Spotify ignoraria o comando em silêncio: avisa em vez de chamar a API
+		return func() tea.Msg { return noticeMsg(control.glyph + " indisponível agora") }, true
+	}
+	switch control.action {
+	case spotify.ActionPausing:
+		action, label = spotifyClient.Pause, "⏸ pausado"
+	case spotify.ActionResuming:
+		action, label = spotifyClient.Play, "▶ tocando"
+	case spotify.ActionSkippingNext:
+		action, label = spotifyClient.Next, "⏭ próxima"
+	case spotify.ActionSkippingPrev:
+		action, label = spotifyClient.Previous, "⏮ anterior"
+	}
+
+	return func() tea.Msg {
+		if err := action(); err != nil {
+			log.Warn("Erro ao controlar o player", "key", key, "error", err)
+			return noticeMsg("falha ao controlar o player")
+		}
+		provider.Refresh()
+		return noticeMsg(label)
+	}, true
+}
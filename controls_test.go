@@ -0,0 +1,255 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withPlayer liga os controles do player durante o teste: um cliente que
+// nunca é chamado e um provider parado.
+func withPlayer(t *testing.T) {
+	t.Helper()
+	prevClient, prevProvider := spotifyClient, provider
+	spotifyClient = spotify.NewClient("id", "secret", "refresh")
+	provider = &Provider{}
+	t.Cleanup(func() { spotifyClient, provider = prevClient, prevProvider })
+}
+
+func TestPlaybackControlGating(t *testing.T) {
+	playing := &spotify.Track{Name: "Song", IsPlaying: true}
+
+	tests := []struct {
+		name    string
+		owner   bool
+		player  bool
+		key     string
+		wantOK  bool
+		wantCmd bool
+	}{
+		{"visitor", false, true, " ", false, false},
+		{"visitor next", false, true, ".", false, false},
+		{"owner without spotify", true, false, " ", false, false},
+		{"owner pause", true, true, " ", true, true},
+		{"owner next", true, true, ".", true, true},
+		{"owner previous", true, true, ",", true, true},
+		{"owner other key", true, true, "x", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.player {
+				withPlayer(t)
+			}
+			m := newModel(80, 24, nil, tt.owner)
+			m.currentTrack = playing
+
+			cmd, ok := m.playbackControl(tt.key)
+			if ok != tt.wantOK || (cmd != nil) != tt.wantCmd {
+				t.Errorf("playbackControl(%q) = cmd %v, ok %v; want cmd %v, ok %v",
+					tt.key, cmd != nil, ok, tt.wantCmd, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPlaybackControlDisallowed(t *testing.T) {
+	withPlayer(t)
+	m := newModel(80, 24, nil, true)
+	m.currentTrack = &spotify.Track{Name: "Song", IsPlaying: true, Disallows: []string{spotify.ActionSkippingPrev}}
+
+	// Ação bloqueada: só o aviso, sem chamar a API
+	cmd, ok := m.playbackControl(",")
+	if !ok || cmd == nil {
+		t.Fatalf("playbackControl(\",\") = cmd %v, ok %v; want a notice", cmd != nil, ok)
+	}
+	if msg, want := cmd(), noticeMsg("⏮ indisponível agora"); msg != want {
+		t.Errorf("notice = %q, want %q", msg, want)
+	}
+}
+
+func TestControlsLineOwnerOnly(t *testing.T) {
+	withPlayer(t)
+	track := &spotify.Track{Name: "Song", IsPlaying: true}
+
+	visitor := newModel(80, 24, nil, false)
+	visitor.currentTrack = track
+	if line := visitor.controlsLine(); line != "" {
+		t.Errorf("visitor controlsLine = %q, want empty", line)
+	}
+
+	owner := newModel(80, 24, nil, true)
+	owner.currentTrack = track
+	if line := owner.controlsLine(); line == "" {
+		t.Error("owner controlsLine is empty")
+	}
+}
+
+func TestNoticeExpires(t *testing.T) {
+	press := func(m model, key string) model {
+		t.Helper()
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return next.(model)
+	}
+	expire := func(m model, gen int) model {
+		t.Helper()
+		next, _ := m.Update(tickerMsg{id: tickerNotice, gen: gen})
+		return next.(model)
+	}
+
+	m := press(newModel(80, 24, nil, false), "n")
+	if m.notice == "" {
+		t.Fatal("n did not show a notice")
+	}
+	first := m.tickers[tickerNotice].gen
+
+	// Um aviso novo recomeça a contagem: o disparo do anterior é ignorado
+	m = press(m, "n")
+	if m = expire(m, first); m.notice == "" {
+		t.Fatal("stale tick cleared the newer notice")
+	}
+	if m = expire(m, m.tickers[tickerNotice].gen); m.notice != "" {
+		t.Errorf("notice = %q after its tick, want empty", m.notice)
+	}
+}
+
+func TestKeysBeforeWindowSize(t *testing.T) {
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("f")},
+		{Type: tea.KeyRunes, Runes: []rune("a")},
+		{Type: tea.KeyRunes, Runes: []rune("n")},
+		{Type: tea.KeyRunes, Runes: []rune("h")},
+		{Type: tea.KeyRunes, Runes: []rune("r")},
+		{Type: tea.KeyRunes, Runes: []rune("d")},
+		{Type: tea.KeyRunes, Runes: []rune(" ")},
+		{Type: tea.KeyEsc},
+		{Type: tea.KeyLeft},
+	}
+	for _, key := range keys {
+		t.Run(key.String(), func(t *testing.T) {
+			before := newModel(0, 0, nil, true)
+			next, cmd := before.Update(key)
+			after := next.(model)
+
+			// Só o horário da última tecla pode mudar
+			after.lastInput = before.lastInput
+			if cmd != nil || !reflect.DeepEqual(after, before) {
+				t.Errorf("key %q before the window size changed the model (cmd %v)", key, cmd != nil)
+			}
+		})
+	}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("q")},
+		{Type: tea.KeyCtrlC},
+		{Type: tea.KeyEnter},
+	} {
+		_, cmd := newModel(0, 0, nil, false).Update(key)
+		if cmd == nil {
+			t.Errorf("%q before the window size: no command, want quit", key)
+			continue
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("%q before the window size did not quit", key)
+		}
+	}
+
+	// Com o tamanho conhecido, as teclas voltam a funcionar
+	m, _ := newModel(0, 0, nil, false).Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if !m.(model).focus {
+		t.Error("f after the window size did not toggle focus")
+	}
+}
+
+func TestClearCacheKey(t *testing.T) {
+	srv, hits := artServer(t, nil)
+	track := &spotify.Track{Name: "Song", ArtworkURL: srv.URL + "/clear-cache"}
+	press := func(m model) (model, tea.Cmd) {
+		t.Helper()
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+		return next.(model), cmd
+	}
+
+	visitor := newModel(80, 24, nil, false)
+	visitor.currentTrack = track
+	loadArt(track, visitor.artMode, visitor.artSeed)()
+	if _, cmd := press(visitor); cmd != nil {
+		t.Error("C in a visitor session returned a command")
+	}
+	loadArt(track, visitor.artMode, visitor.artSeed)()
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d downloads after a visitor pressed C, want 1", n)
+	}
+
+	owner := newModel(80, 24, nil, true)
+	owner.currentTrack = track
+	owner, cmd := press(owner)
+	if owner.notice == "" || cmd == nil {
+		t.Fatalf("owner C: notice %q, cmd %v; want a notice and the art reload", owner.notice, cmd != nil)
+	}
+	loadArt(track, owner.artMode, owner.artSeed)()
+	if n := hits.Load(); n != 2 {
+		t.Errorf("%d downloads after the owner cleared the cache, want 2", n)
+	}
+}
+
+func TestDebugFooterOwnerOnly(t *testing.T) {
+	press := func(m model) model {
+		t.Helper()
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		return next.(model)
+	}
+
+	if m := press(newModel(80, 24, nil, false)); m.debug {
+		t.Error("d turned on the debug footer for a visitor")
+	}
+	m := press(newModel(80, 24, nil, true))
+	if !m.debug {
+		t.Fatal("d did not turn on the debug footer for the owner")
+	}
+	if m = press(m); m.debug {
+		t.Error("second d did not turn the debug footer off")
+	}
+}
+
+func TestPinHoldsTrack(t *testing.T) {
+	m := viewModel()
+	m.loaded = true
+	pinned := m.currentTrack
+	press := func(m model, key string) model {
+		t.Helper()
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return next.(model)
+	}
+
+	m = press(m, "p")
+	if !m.pinned {
+		t.Fatal("p did not pin the track")
+	}
+
+	// Enquanto fixado, as atualizações só ficam guardadas, a última vencendo
+	for _, name := range []string{"Outra", "Mais Nova"} {
+		next, _ := m.Update(trackMsg{track: &spotify.Track{Name: name, Artist: "Artista", IsPlaying: true}, at: time.Now()})
+		m = next.(model)
+	}
+	if m.currentTrack != pinned {
+		t.Errorf("pinned widget shows %q, want %q", m.currentTrack.Name, pinned.Name)
+	}
+	if view := m.View(); strings.Contains(view, "Mais Nova") {
+		t.Errorf("pinned view shows the provider update:\n%s", view)
+	}
+
+	m = press(m, "p")
+	if m.pinned || m.pending != nil {
+		t.Fatalf("after unpinning: pinned %v, pending %v", m.pinned, m.pending)
+	}
+	if m.currentTrack.Name != "Mais Nova" {
+		t.Errorf("after unpinning the widget shows %q, want the latest update", m.currentTrack.Name)
+	}
+}
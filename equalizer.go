@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// eqLevels é o ciclo de alturas de cada barra do equalizador.
+var eqLevels = []rune("▁▃▅▇▅▃")
+
+// Equalizador do widget vazio: eqBars barras, um quadro a cada eqInterval.
+// Depois de eqIdle sem teclas ele para nas barras baixas, para não acordar
+// a sessão a cada eqInterval enquanto nada toca e ninguém olha.
+const (
+	eqBars     = 7
+	eqInterval = 150 * time.Millisecond
+	eqIdle     = time.Minute
+)
+
+// equalizer retorna o quadro frame do equalizador animado.
+// Cada barra percorre eqLevels com uma defasagem diferente, para que as
+// barras não subam e desçam juntas.
+func equalizer(frame int) string {
+	bars := make([]rune, 0, eqBars*2-1)
+	for i := range eqBars {
+		if i > 0 {
+			bars = append(bars, ' ')
+		}
+		// Defasagem irregular (i*i) para parecer menos mecânico
+		bars = append(bars, eqLevels[(frame+i*i)%len(eqLevels)])
+	}
+	return string(bars)
+}
+
+// flatEqualizer retorna o equalizador parado, com todas as barras no
+// nível mais baixo.
+func flatEqualizer() string {
+	return strings.TrimSpace(strings.Repeat(string(eqLevels[0])+" ", eqBars))
+}
+
+// eqAnimating informa se o equalizador deve continuar animando: só com
+// o widget vazio na tela e alguém mexendo na sessão há menos de eqIdle.
+func (m model) eqAnimating() bool {
+	return m.currentTrack == nil && time.Since(m.lastInput) < eqIdle
+}
+
+// eqView retorna o quadro atual do equalizador, ou as barras paradas se o
+// ticker dele não está rodando.
+func (m model) eqView() string {
+	if !m.tickers.running(tickerEqualizer) {
+		return flatEqualizer()
+	}
+	return equalizer(m.eqFrame)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEqualizerFrames(t *testing.T) {
+	if got, want := equalizer(0), "▁ ▃ ▅ ▇ ▅ ▃ ▁"; got != want {
+		t.Errorf("equalizer(0) = %q, want %q", got, want)
+	}
+	for frame := range 2 * len(eqLevels) {
+		got := equalizer(frame)
+		if n := utf8.RuneCountInString(got); n != eqBars*2-1 {
+			t.Errorf("equalizer(%d) has %d runes, want %d", frame, n, eqBars*2-1)
+		}
+		if next := equalizer(frame + 1); next == got {
+			t.Errorf("equalizer(%d) == equalizer(%d) = %q, want a new frame", frame, frame+1, got)
+		}
+		// O ciclo se repete a cada len(eqLevels) quadros
+		if loop := equalizer(frame + len(eqLevels)); loop != got {
+			t.Errorf("equalizer(%d) = %q, equalizer(%d) = %q; want the same frame", frame, got, frame+len(eqLevels), loop)
+		}
+	}
+}
+
+func TestEqualizerStopsWhenIdle(t *testing.T) {
+	m := newModel(120, 40, nil, false)
+	m.loaded = true
+	m.lastInput = time.Now().Add(-eqIdle)
+
+	next, cmd := m.Update(tickerMsg{id: tickerEqualizer, gen: m.tickers[tickerEqualizer].gen})
+	m = next.(model)
+	if cmd != nil || m.tickers.running(tickerEqualizer) {
+		t.Fatal("equalizer still ticking with nothing playing and nobody around")
+	}
+	if !strings.Contains(m.View(), flatEqualizer()) {
+		t.Errorf("idle view does not show the flat equalizer:\n%s", m.View())
+	}
+
+	// Qualquer tecla volta a animar
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = next.(model)
+	if cmd == nil || !m.tickers.running(tickerEqualizer) {
+		t.Fatal("key press did not restart the equalizer")
+	}
+	next, _ = m.Update(tickerMsg{id: tickerEqualizer, gen: m.tickers[tickerEqualizer].gen})
+	if m = next.(model); m.eqFrame != 1 {
+		t.Errorf("eqFrame = %d after a tick, want 1", m.eqFrame)
+	}
+}
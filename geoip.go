@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// geoRange é um intervalo de IPs atribuído a um país.
+type geoRange struct {
+	start, end netip.Addr
+	country    string // Código ISO 3166-1 alfa-2 (BR, US...)
+}
+
+// geoDB é a base GeoIP local (GEOIP_DB), ordenada por início do intervalo.
+// Vazia, a saudação com o país fica desligada.
+var geoDB []geoRange
+
+// loadGeoDB carrega uma base de países em CSV com linhas
+// "ip_inicial,ip_final,país", IPv4 ou IPv6 (o formato do DB-IP Lite, por
+// exemplo). Linhas que não seguem o formato, como um cabeçalho, são
+// ignoradas.
+//
+// A consulta é sempre local: o IP de quem conecta nunca sai do servidor.
+func loadGeoDB(path string) ([]geoRange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	var db []geoRange
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 3 {
+			continue
+		}
+		start, err1 := netip.ParseAddr(strings.TrimSpace(rec[0]))
+		end, err2 := netip.ParseAddr(strings.TrimSpace(rec[1]))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		db = append(db, geoRange{start: start.Unmap(), end: end.Unmap(), country: strings.ToUpper(strings.TrimSpace(rec[2]))})
+	}
+
+	slices.SortFunc(db, func(a, b geoRange) int { return a.start.Compare(b.start) })
+	return db, nil
+}
+
+// lookupCountry retorna o código do país de addr em db, ou "" se o IP não
+// for público (rede privada, loopback...) ou não estiver na base.
+func lookupCountry(db []geoRange, addr netip.Addr) string {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return ""
+	}
+	i := sort.Search(len(db), func(i int) bool { return db[i].start.Compare(addr) > 0 }) - 1
+	if i < 0 || db[i].end.Compare(addr) < 0 {
+		return ""
+	}
+	return db[i].country
+}
+
+// greeting monta a saudação do rodapé para quem conecta de remote, com o
+// nome do país no idioma ativo. Vazia sem base ou com país desconhecido.
+func greeting(remote net.Addr) string {
+	if len(geoDB) == 0 || remote == nil {
+		return ""
+	}
+	ap, err := netip.ParseAddrPort(remote.String())
+	if err != nil {
+		return ""
+	}
+	region, err := language.ParseRegion(lookupCountry(geoDB, ap.Addr()))
+	if err != nil {
+		return ""
+	}
+	name := display.Regions(text.Lang).Name(region)
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(text.Greeting, name)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testGeoDB escreve uma base no formato do DB-IP Lite e a carrega.
+func testGeoDB(t *testing.T) []geoRange {
+	t.Helper()
+	csv := "ip_start,ip_end,country\n" +
+		"1.0.0.0,1.0.0.255,au\n" +
+		"200.128.0.0,200.255.255.255,BR\n" +
+		"8.8.8.0, 8.8.8.255, US\n" +
+		"linha quebrada\n" +
+		"2001:db8::,2001:db8::ffff,DE\n" +
+		"2804::,2804:ffff:ffff:ffff:ffff:ffff:ffff:ffff,BR\n"
+	path := filepath.Join(t.TempDir(), "geo.csv")
+	if err := os.WriteFile(path, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := loadGeoDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLookupCountry(t *testing.T) {
+	db := testGeoDB(t)
+	if len(db) != 5 {
+		t.Fatalf("loaded %d ranges, want 5", len(db))
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"200.160.2.3", "BR"},
+		{"200.128.0.0", "BR"},
+		{"200.255.255.255", "BR"},
+		{"1.0.0.1", "AU"},
+		{"8.8.8.8", "US"},
+		{"::ffff:8.8.8.8", "US"},
+		{"2804:14c::1", "BR"},
+		{"9.9.9.9", ""},  // Entre intervalos
+		{"0.0.0.1", ""},  // Antes do primeiro
+		{"10.0.0.1", ""}, // Rede privada
+		{"192.168.1.1", ""},
+		{"127.0.0.1", ""}, // Loopback
+		{"::1", ""},
+		{"fd00::1", ""}, // IPv6 privado
+	}
+	for _, tt := range tests {
+		if got := lookupCountry(db, netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("lookupCountry(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestGreeting(t *testing.T) {
+	prev := geoDB
+	geoDB = testGeoDB(t)
+	t.Cleanup(func() { geoDB = prev })
+
+	tests := []struct {
+		addr net.Addr
+		want string
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("200.160.2.3"), Port: 50022}, "👋 olá, visitante! (Brasil)"},
+		{&net.TCPAddr{IP: net.ParseIP("8.8.8.8"), Port: 50022}, "👋 olá, visitante! (Estados Unidos)"},
+		{&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 50022}, ""},
+		{&net.TCPAddr{IP: net.ParseIP("9.9.9.9"), Port: 50022}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := greeting(tt.addr); got != tt.want {
+			t.Errorf("greeting(%v) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	geoDB = nil
+	if got := greeting(tests[0].addr); got != "" {
+		t.Errorf("greeting without a database = %q, want empty", got)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// sshUp indica se o listener SSH está aceitando conexões.
+var sshUp atomic.Bool
+
+// readinessTTL é por quanto tempo o resultado da checagem do Spotify é reaproveitado.
+const readinessTTL = 30 * time.Second
+
+// spotifyCheck cacheia o resultado da checagem de token do Spotify,
+// para que probes frequentes não batam no endpoint de token.
+type spotifyCheck struct {
+	client *spotify.Client
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// status retorna "disabled" sem cliente, "ok" ou a mensagem de erro.
+func (c *spotifyCheck) status() (string, bool) {
+	if c.client == nil {
+		return "disabled", true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checkedAt) >= readinessTTL {
+		c.err = c.client.CheckAuth()
+		c.checkedAt = time.Now()
+	}
+
+	if c.err != nil {
+		return c.err.Error(), false
+	}
+	return "ok", true
+}
+
+// healthResponse é o corpo JSON de /healthz e /readyz.
+type healthResponse struct {
+	Status  string `json:"status"`
+	SSH     bool   `json:"ssh"`
+	Spotify string `json:"spotify,omitempty"`
+}
+
+// handleHealthz responde 200 enquanto o listener SSH estiver de pé (liveness).
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{Status: "ok", SSH: sshUp.Load()}
+	code := http.StatusOK
+	if !resp.SSH {
+		resp.Status = "down"
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, resp)
+}
+
+// handleReadyz também exige que o Spotify (se configurado) consiga
+// obter um access token (readiness).
+func handleReadyz(check *spotifyCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		spotifyStatus, spotifyOK := check.status()
+		resp := healthResponse{Status: "ok", SSH: sshUp.Load(), Spotify: spotifyStatus}
+		code := http.StatusOK
+		if !resp.SSH || !spotifyOK {
+			resp.Status = "not ready"
+			code = http.StatusServiceUnavailable
+		}
+		writeHealth(w, code, resp)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, code int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"ssh-portfolio/spotify"
+)
+
+// newHTTPHandler monta as rotas HTTP auxiliares do servidor.
+//
+//	GET /healthz             → liveness: o listener SSH está de pé
+//	GET /readyz              → readiness: SSH de pé e token do Spotify válido
+//	GET /metrics             → métricas no formato do Prometheus
+//	GET /now-playing         → música atual em JSON (null se não houver)
+//	GET /now-playing/stream  → Server-Sent Events a cada troca de música
+//	GET /now-playing.png     → card da música atual (capa + texto) em PNG
+//
+// As rotas /now-playing só existem com o Spotify configurado (p != nil).
+func newHTTPHandler(p *Provider, client *spotify.Client) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", handleHealthz)
+	mux.HandleFunc("GET /readyz", handleReadyz(&spotifyCheck{client: client}))
+	mux.HandleFunc("GET /metrics", handleMetrics(p))
+	if p != nil {
+		mux.HandleFunc("GET /now-playing", handleNowPlaying(p))
+		mux.HandleFunc("GET /now-playing/stream", handleNowPlayingStream(p))
+		mux.HandleFunc("GET /now-playing.png", handleCard(p))
+	}
+	return mux
+}
+
+// newHTTPServer cria o servidor HTTP auxiliar em addr. Os streams SSE só
+// terminam quando o cliente desconecta, então o Shutdown cancela o
+// contexto base das requests para encerrá-los; sem isso ele esperaria o
+// prazo inteiro com um único espectador conectado.
+func newHTTPServer(addr string, p *Provider, client *spotify.Client) *http.Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     newHTTPHandler(p, client),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	srv.RegisterOnShutdown(cancel)
+	return srv
+}
+
+func handleNowPlaying(p *Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Current().track)
+	}
+}
+
+// handleNowPlayingStream envia um evento "track" com a música atual ao
+// conectar e outro sempre que ela muda, até o cliente desconectar ou o
+// servidor encerrar (ver newHTTPServer). Erros de polling são ignorados:
+// o cliente continua com a última música conhecida.
+func handleNowPlayingStream(p *Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		flusher.Flush()
+
+		updates, unsubscribe := p.Subscribe()
+		defer unsubscribe()
+
+		var last *spotify.Track
+		sent := false
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case u, ok := <-updates:
+				if !ok {
+					return
+				}
+				if u.err != nil || (sent && sameTrack(last, u.track)) {
+					continue
+				}
+
+				data, err := json.Marshal(u.track)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: track\ndata: %s\n\n", data)
+				flusher.Flush()
+
+				last, sent = u.track, true
+			}
+		}
+	}
+}
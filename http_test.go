@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// newTestProvider cria um provider sem loop de polling: os resultados
+// entram só por publish.
+func newTestProvider() *Provider {
+	return &Provider{
+		subs: make(map[chan trackUpdate]struct{}),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// receive lê a próxima atualização de ch, falhando após 2s.
+func receive(t *testing.T, ch <-chan trackUpdate) trackUpdate {
+	t.Helper()
+	select {
+	case u := <-ch:
+		return u
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an update")
+		return trackUpdate{}
+	}
+}
+
+func TestProviderBroadcast(t *testing.T) {
+	p := newTestProvider()
+	a, unsubA := p.Subscribe()
+	defer unsubA()
+	b, unsubB := p.Subscribe()
+	defer unsubB()
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One"}})
+	for _, ch := range []<-chan trackUpdate{a, b} {
+		if u := receive(t, ch); u.track.Name != "One" {
+			t.Errorf("got %q, want One", u.track.Name)
+		}
+	}
+}
+
+func TestProviderSlowSubscriberGetsLatest(t *testing.T) {
+	p := newTestProvider()
+	ch, unsub := p.Subscribe()
+	defer unsub()
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One"}})
+	p.publish(trackUpdate{track: &spotify.Track{Name: "Two"}})
+	if u := receive(t, ch); u.track.Name != "Two" {
+		t.Errorf("got %q, want Two", u.track.Name)
+	}
+	select {
+	case u := <-ch:
+		t.Errorf("unexpected second update %q", u.track.Name)
+	default:
+	}
+}
+
+func TestProviderLateSubscriberGetsLast(t *testing.T) {
+	p := newTestProvider()
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One"}})
+
+	ch, unsub := p.Subscribe()
+	defer unsub()
+	if u := receive(t, ch); u.track.Name != "One" {
+		t.Errorf("got %q, want One", u.track.Name)
+	}
+}
+
+func TestProviderUnsubscribe(t *testing.T) {
+	p := newTestProvider()
+	ch, unsub := p.Subscribe()
+	unsub()
+	unsub() // Idempotente
+
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+	if n := p.subscribers(); n != 0 {
+		t.Errorf("%d subscribers, want 0", n)
+	}
+	p.publish(trackUpdate{}) // Não pode escrever no canal fechado
+}
+
+// serveHTTP sobe newHTTPServer numa porta livre e retorna sua URL.
+func serveHTTP(t *testing.T, p *Provider) (*http.Server, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(ln.Addr().String(), p, nil)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return srv, "http://" + ln.Addr().String()
+}
+
+// sseReader lê os eventos "track" de um stream SSE.
+type sseReader struct {
+	t    *testing.T
+	scan *bufio.Scanner
+}
+
+// next retorna o nome da música do próximo evento.
+func (r sseReader) next() string {
+	r.t.Helper()
+	for r.scan.Scan() {
+		data, ok := strings.CutPrefix(r.scan.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var track *spotify.Track
+		if err := json.Unmarshal([]byte(data), &track); err != nil {
+			r.t.Fatal(err)
+		}
+		if track == nil {
+			return ""
+		}
+		return track.Name
+	}
+	r.t.Fatalf("stream ended: %v", r.scan.Err())
+	return ""
+}
+
+func openStream(t *testing.T, url string) sseReader {
+	t.Helper()
+	resp, err := http.Get(url + "/now-playing/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	return sseReader{t, bufio.NewScanner(resp.Body)}
+}
+
+func TestNowPlayingStream(t *testing.T) {
+	p := newTestProvider()
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One", IsPlaying: true}})
+	_, url := serveHTTP(t, p)
+
+	stream := openStream(t, url)
+	if got := stream.next(); got != "One" {
+		t.Fatalf("first event = %q, want One", got)
+	}
+
+	// A mesma música e erros de polling não geram eventos
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One", IsPlaying: true}})
+	p.publish(trackUpdate{err: context.DeadlineExceeded})
+	p.publish(trackUpdate{track: &spotify.Track{Name: "Two", IsPlaying: true}})
+	if got := stream.next(); got != "Two" {
+		t.Fatalf("next event = %q, want Two", got)
+	}
+}
+
+func TestHTTPShutdownEndsStreams(t *testing.T) {
+	p := newTestProvider()
+	p.publish(trackUpdate{track: &spotify.Track{Name: "One"}})
+	srv, url := serveHTTP(t, p)
+
+	stream := openStream(t, url)
+	stream.next()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown with an open stream: %v", err)
+	}
+	if n := p.subscribers(); n != 0 {
+		t.Errorf("%d subscribers after shutdown, want 0", n)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "golang.org/x/text/language"
+
+// messages são os textos da interface que variam com o idioma (LOCALE).
+type messages struct {
+	Loading string // Tela de carregamento, antes das reticências animadas
+	Title   string // Título padrão do widget, quando TITLE_TEMPLATE não está definido
+
+	Quit      string // Rodapé com a instrução para sair
+	QuitShort string // Versão curta de Quit, para terminais estreitos
+	Watching  string // Contador de sessões do dono; recebe o número (%d)
+	Greeting  string // Saudação com o país de quem conectou (%s), com GEOIP_DB
+	Welcome   string // Boas-vindas ao conectar, com WELCOME=true
+	Today     string // Músicas tocadas hoje; recebe o número (%d)
+	TodayOne  string // Today para uma música só
+	About     string // Painel "sobre" com PLAYING_ONLY, sem ABOUT_FILE nem logo
+
+	Offline     string // Aviso de Spotify fora do ar, sem música conhecida
+	OfflineLast string // Offline com a última música conhecida embaixo
+
+	Lang language.Tag // Idioma dos nomes de países
+}
+
+// locales são os idiomas embutidos, selecionáveis pela variável LOCALE.
+var locales = map[string]messages{
+	"pt": {
+		Loading:   "● Carregando",
+		Title:     "♫ Spotify",
+		Quit:      "Pressione q ou Enter para sair",
+		QuitShort: "q: sair",
+		Watching:  "👀 %d assistindo",
+		Greeting:  "👋 olá, visitante! (%s)",
+		Welcome:   "Bem-vindo! Veja o que estou ouvindo agora",
+		Today:     "🎧 %d músicas hoje",
+		TodayOne:  "🎧 1 música hoje",
+		About:     "Nada tocando agora",
+
+		Offline:     "⚠ Spotify indisponível",
+		OfflineLast: "⚠ Spotify indisponível — mostrando a última música conhecida",
+		Lang:        language.BrazilianPortuguese,
+	},
+	"en": {
+		Loading:   "● Loading",
+		Title:     "♫ Spotify",
+		Quit:      "Press q or Enter to quit",
+		QuitShort: "q: quit",
+		Watching:  "👀 %d watching",
+		Greeting:  "👋 hello from %s",
+		Welcome:   "Welcome! Here's what I'm listening to",
+		Today:     "🎧 %d songs today",
+		TodayOne:  "🎧 1 song today",
+		About:     "Nothing playing right now",
+
+		Offline:     "⚠ Spotify unavailable",
+		OfflineLast: "⚠ Spotify unavailable — showing last known track",
+		Lang:        language.English,
+	},
+}
+
+// defaultLocale é o idioma usado quando LOCALE não está definido ou é desconhecido.
+const defaultLocale = "pt"
+
+// text são os textos do idioma ativo, compartilhados por todas as sessões.
+var text = locales[defaultLocale]
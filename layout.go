@@ -0,0 +1,49 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+// alignment é uma posição do widget na tela.
+type alignment struct {
+	name       string
+	horizontal lipgloss.Position
+	vertical   lipgloss.Position
+}
+
+// alignments são as posições disponíveis, na ordem em que a tecla a as percorre.
+// A primeira é o padrão.
+var alignments = []alignment{
+	{"center", lipgloss.Center, lipgloss.Center},
+	{"top", lipgloss.Center, lipgloss.Top},
+	{"top-left", lipgloss.Left, lipgloss.Top},
+	{"top-right", lipgloss.Right, lipgloss.Top},
+	{"bottom-left", lipgloss.Left, lipgloss.Bottom},
+	{"bottom-right", lipgloss.Right, lipgloss.Bottom},
+}
+
+// defaultAlignment é o índice em alignments usado pelas novas sessões (WIDGET_ALIGN).
+var defaultAlignment = 0
+
+// alignmentIndex retorna o índice da posição com o nome dado.
+func alignmentIndex(name string) (int, bool) {
+	for i, a := range alignments {
+		if a.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// topPadding calcula quantas linhas vazias pôr acima de um conteúdo com
+// contentHeight linhas para posicioná-lo verticalmente numa tela de height linhas.
+// Nunca é negativo: se o conteúdo não cabe, ele fica colado no topo.
+func topPadding(vertical lipgloss.Position, contentHeight, height int) int {
+	free := max(height-contentHeight, 0)
+	switch vertical {
+	case lipgloss.Top:
+		return 0
+	case lipgloss.Bottom:
+		return free
+	default:
+		return free / 2
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ssh-portfolio/albumart"
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestChooseLayout(t *testing.T) {
+	chrome := styles().widget.GetHorizontalFrameSize()
+	artFrame := artWidth + styles().artFrame.GetHorizontalFrameSize()
+	horizontal := artFrame + textWidth + chrome
+	stacked := max(artFrame, textWidth) + chrome
+
+	tests := []struct {
+		width int
+		want  widgetLayout
+	}{
+		{200, layoutHorizontal},
+		{horizontal, layoutHorizontal},
+		{horizontal - 1, layoutStacked},
+		{stacked, layoutStacked},
+		{stacked - 1, layoutTextOnly},
+		{10, layoutTextOnly},
+	}
+	for _, tt := range tests {
+		if got := chooseLayout(tt.width); got != tt.want {
+			t.Errorf("chooseLayout(%d) = %d, want %d", tt.width, got, tt.want)
+		}
+	}
+}
+
+// assertFits falha se alguma linha de view passar de width colunas.
+func assertFits(t *testing.T, view string, width int) {
+	t.Helper()
+	for i, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > width {
+			t.Fatalf("width %d: line %d is %d columns:\n%s", width, i, w, view)
+		}
+	}
+}
+
+func TestViewFitsWidth(t *testing.T) {
+	long := strings.Repeat("Nome Muito Comprido ", 5)
+	track := &spotify.Track{
+		Name:        long,
+		Artist:      "Artista",
+		Artists:     []string{"Artista", "Outro Artista", "Mais Um"},
+		Album:       long,
+		IsPlaying:   true,
+		DurationMs:  200000,
+		ProgressMs:  50000,
+		TrackNumber: 3,
+		AlbumTracks: 12,
+	}
+
+	models := map[string]func(width int) model{
+		"empty": func(width int) model {
+			return newModel(width, 40, nil, false)
+		},
+		"track": func(width int) model {
+			m := newModel(width, 40, nil, false)
+			m.currentTrack = track
+			m.art = albumart.RenderGenerated("seed", artWidth, artHeight, albumart.Options{})
+			m.artFor = artKey(track)
+			return m
+		},
+		"focus": func(width int) model {
+			m := newModel(width, 40, nil, false)
+			m.currentTrack = track
+			m.focus = true
+			return m
+		},
+		"offline": func(width int) model {
+			m := newModel(width, 40, nil, false)
+			m.currentTrack = track
+			m.failures = offlineThreshold
+			return m
+		},
+	}
+
+	for name, build := range models {
+		t.Run(name, func(t *testing.T) {
+			for width := 10; width <= 120; width++ {
+				assertFits(t, build(width).View(), width)
+			}
+		})
+	}
+}
+
+func TestTruncateMultibyte(t *testing.T) {
+	for _, s := range []string{"Canção de ninar", "日本語のタイトル", "🎵🎶🎵🎶🎵🎶"} {
+		for width := 0; width <= lipgloss.Width(s); width++ {
+			got := truncate(s, width)
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q splits a rune", s, width, got)
+			}
+			if w := lipgloss.Width(got); w > width {
+				t.Errorf("truncate(%q, %d) is %d columns", s, width, w)
+			}
+		}
+	}
+}
+
+func TestViewWithHugeNames(t *testing.T) {
+	// Um nome de vários KB chega cortado pelo cliente, mas o layout não
+	// pode depender disso
+	huge := strings.Repeat("Nome Enorme ", 500)
+	defer func(prev textWrapMode) { textWrap = prev }(textWrap)
+	for _, mode := range []textWrapMode{wrapOff, wrapOn} {
+		textWrap = mode
+		m := newModel(100, 40, nil, false)
+		m.currentTrack = &spotify.Track{Name: huge, Artist: huge, Artists: []string{huge}, Album: huge, IsPlaying: true}
+		view := m.View()
+		assertFits(t, view, 100)
+		if lines := strings.Count(view, "\n") + 1; lines > 40 {
+			t.Errorf("wrap mode %d: %d lines, want at most 40", mode, lines)
+		}
+	}
+}
+
+func TestAlignmentIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   int
+		wantOK bool
+	}{
+		{"center", 0, true},
+		{"top", 1, true},
+		{"bottom-right", 5, true},
+		{"bottom", 0, false},
+		{"CENTER", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, ok := alignmentIndex(tt.name)
+			if i != tt.want || ok != tt.wantOK {
+				t.Errorf("alignmentIndex(%q) = %d, %v; want %d, %v", tt.name, i, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTopPadding(t *testing.T) {
+	tests := []struct {
+		name            string
+		vertical        lipgloss.Position
+		content, height int
+		want            int
+	}{
+		{"top", lipgloss.Top, 10, 40, 0},
+		{"bottom", lipgloss.Bottom, 10, 40, 30},
+		{"center", lipgloss.Center, 10, 40, 15},
+		{"center odd", lipgloss.Center, 10, 41, 15},
+		{"exact fit", lipgloss.Bottom, 40, 40, 0},
+		{"bottom taller than screen", lipgloss.Bottom, 50, 40, 0},
+		{"center taller than screen", lipgloss.Center, 50, 40, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topPadding(tt.vertical, tt.content, tt.height); got != tt.want {
+				t.Errorf("topPadding(%v, %d, %d) = %d, want %d", tt.vertical, tt.content, tt.height, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArtistLine(t *testing.T) {
+	five := []string{"Ana", "Bia", "Caio", "Duda", "Enzo"}
+	tests := []struct {
+		name    string
+		artists []string
+		width   int
+		want    string
+	}{
+		{"one fits", []string{"Ana"}, 20, "Ana"},
+		{"one truncated", []string{"Anastácia Maria"}, 10, "Anastác..."},
+		{"two fit", []string{"Ana", "Bia"}, 20, "Ana, Bia"},
+		{"two summarized", []string{"Ana", "Bianca Beatriz"}, 14, "Ana feat. +1"},
+		{"five fit", five, 40, "Ana, Bia, Caio, Duda, Enzo"},
+		{"five summarized", five, 14, "Ana feat. +4"},
+		{"five truncated", five, 8, "Ana"},
+		{"main truncated", []string{"Anastácia Maria", "Bia"}, 10, "Anastác..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := artistLine(&spotify.Track{Artist: tt.artists[0], Artists: tt.artists}, tt.width)
+			if got != tt.want {
+				t.Errorf("artistLine(%v, %d) = %q, want %q", tt.artists, tt.width, got, tt.want)
+			}
+			if w := lipgloss.Width(got); w > tt.width {
+				t.Errorf("artistLine is %d columns wide, want at most %d", w, tt.width)
+			}
+		})
+	}
+
+	// Sem a lista, usa o artista principal ou o nome genérico
+	if got := artistLine(&spotify.Track{Artist: "Ana"}, 20); got != "Ana" {
+		t.Errorf("artistLine without Artists = %q, want Ana", got)
+	}
+	if got := artistLine(&spotify.Track{}, 30); got != "Artista desconhecido" {
+		t.Errorf("artistLine without artists = %q, want Artista desconhecido", got)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/charmbracelet/log"
+)
+
+// listenSSH abre a porta do servidor SSH.
+//
+// Portas abaixo de 1024 exigem privilégios: sem eles o bind falha com
+// EACCES. Nesse caso o erro explica como resolver e, se SSH_FALLBACK_PORT
+// estiver definida, tenta essa porta antes de desistir.
+func listenSSH(host, port string) (net.Listener, string, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err == nil || !errors.Is(err, syscall.EACCES) {
+		return ln, port, err
+	}
+
+	log.Error("Sem permissão para abrir a porta",
+		"port", port,
+		"hint", "use uma porta acima de 1024 (SSH_FALLBACK_PORT=2222) ou conceda a capability: sudo setcap 'cap_net_bind_service=+ep' <binário>",
+	)
+
+	fallback := os.Getenv("SSH_FALLBACK_PORT")
+	if fallback == "" || fallback == port {
+		return nil, port, err
+	}
+
+	log.Warn("Usando porta alternativa", "port", fallback)
+	ln, err = net.Listen("tcp", net.JoinHostPort(host, fallback))
+	return ln, fallback, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// idleLogo é o logo ASCII do widget vazio (IDLE_LOGO_FILE), uma linha
+// por elemento. Vazio mostra só o texto "Nenhuma música".
+var idleLogo []string
+
+// maxLogoLines limita a altura do logo, para um arquivo errado não
+// empurrar o resto da tela para fora.
+const maxLogoLines = 20
+
+// loadLogo lê o logo de path: tabs viram espaços, escapes e caracteres
+// de controle são removidos (o arquivo vai direto para o terminal) e
+// linhas em branco nas pontas são descartadas.
+func loadLogo(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	text = ansi.Strip(text)
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if r < ' ' || r == 0x7f {
+				return -1
+			}
+			return r
+		}, line)
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines[:min(len(lines), maxLogoLines)], nil
+}
+
+// renderLogo renderiza o logo como um bloco alinhado à esquerda (centrar
+// linha a linha entortaria o desenho), cortando à direita as linhas mais
+// largas que width.
+func renderLogo(lines []string, width int) string {
+	cut := make([]string, len(lines))
+	for i, line := range lines {
+		cut[i] = ansi.Truncate(line, max(width, 0), "")
+	}
+	return styles().title.Render(strings.Join(cut, "\n"))
+}
+
+// idleWidth é a largura disponível dentro do widget vazio.
+func (m model) idleWidth() int {
+	return m.width - styles().emptyWidget.GetHorizontalFrameSize()
+}
+
+// idleContent é a linha de baixo do widget vazio: o logo, se houver e
+// couber minimamente, ou o texto padrão.
+func (m model) idleContent() string {
+	if len(idleLogo) == 0 || m.idleWidth() < 8 {
+		return styles().artist.Render("Nenhuma música")
+	}
+	return renderLogo(idleLogo, m.idleWidth())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestLoadLogo(t *testing.T) {
+	raw := "\r\n\n  /\\_/\\\r\n\t( o.o )\x1b[31m \n  > ^ <\x07   \n\n"
+	path := filepath.Join(t.TempDir(), "logo.txt")
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := loadLogo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"  /\\_/\\", "    ( o.o )", "  > ^ <"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("loadLogo = %q, want %q", lines, want)
+	}
+}
+
+func TestLoadLogoLimitsHeight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logo.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("#\n", 100)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if lines, err := loadLogo(path); err != nil || len(lines) != maxLogoLines {
+		t.Errorf("loadLogo = %d lines, %v; want %d", len(lines), err, maxLogoLines)
+	}
+}
+
+func TestIdleWidgetLogo(t *testing.T) {
+	prev := idleLogo
+	idleLogo = []string{"  /\\_/\\", " ( o.o )", "  > ^ <"}
+	t.Cleanup(func() { idleLogo = prev })
+
+	m := newModel(80, 40, nil, false)
+	view := ansi.Strip(m.View())
+	for _, line := range idleLogo {
+		if !strings.Contains(view, line) {
+			t.Errorf("idle view is missing logo line %q:\n%s", line, view)
+		}
+	}
+	if strings.Contains(view, "Nenhuma música") {
+		t.Error("idle view shows the text along with the logo")
+	}
+
+	// Estreito demais para o logo: volta ao texto, sem passar da largura
+	for width := 10; width <= 30; width++ {
+		assertFits(t, newModel(width, 40, nil, false).View(), width)
+	}
+
+	// Sem logo, o texto de sempre
+	idleLogo = nil
+	if view := ansi.Strip(newModel(80, 40, nil, false).View()); !strings.Contains(view, "Nenhuma música") {
+		t.Errorf("idle view without a logo:\n%s", view)
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,7 +25,7 @@ import (
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
-	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
@@ -25,225 +33,1606 @@ const (
 	port = "22"
 )
 
-var spotifyClient *spotify.Client
+var (
+	spotifyClient *spotify.Client
+
+	// provider é compartilhado por todas as sessões; nil se o Spotify está desabilitado
+	provider *Provider
+
+	// artOptions define como a capa é renderizada em todas as sessões
+	artOptions albumart.Options
+
+	// defaultArtMode é o modo de arte das novas sessões (ALBUMART_MODE)
+	defaultArtMode = artBlocks
+
+	// autoArtMode escolhe o modo de arte pelo terminfo de cada sessão (ALBUMART_MODE=auto)
+	autoArtMode bool
+
+	// artistCollage troca a capa por uma colagem das fotos dos artistas
+	// em músicas com mais de um artista (ALBUMART_COLLAGE)
+	artistCollage bool
+
+	// altScreen decide quando usar a tela alternativa (ALT_SCREEN)
+	altScreen = altScreenAuto
+
+	// sessionArtSeed varia por sessão a arte gerada para músicas sem capa
+	// (ALBUMART_SESSION_SEED); por padrão ela depende só da música
+	sessionArtSeed bool
+
+	// artFallback indica que há uma imagem de fallback (ALBUMART_FALLBACK),
+	// usada também no lugar da arte gerada para músicas sem capa
+	artFallback bool
+)
+
+// altScreenMode define quando as sessões usam a tela alternativa do terminal.
+type altScreenMode int
+
+const (
+	altScreenAuto   altScreenMode = iota // Conforme o terminfo da sessão (padrão)
+	altScreenAlways                      // Sempre, mesmo sem smcup no terminfo
+	altScreenNever                       // Nunca: o widget é desenhado inline
+)
+
+// artMode define como a capa é desenhada.
+type artMode int
 
-type tickMsg time.Time
+const (
+	artBlocks artMode = iota // Half-blocks coloridos (padrão)
+	artSketch                // Esboço ASCII das bordas da capa
+)
 
-type trackMsg struct {
-	track *spotify.Track
-	err   error
+// trackMsg carrega uma atualização do provider para o model.
+type trackMsg trackUpdate
+
+// artMsg carrega uma capa renderizada em segundo plano.
+type artMsg struct {
+	key string // artKey da música para a qual a capa foi renderizada
+	art string
+	err error
 }
 
 type model struct {
 	width        int
 	height       int
 	currentTrack *spotify.Track
+	queue        *spotify.Queue
+	fetchedAt    time.Time        // Quando a música atual foi buscada (base do progresso)
+	history      []*spotify.Track // Histórico recente, para o sparkline de atividade
+	tickers      tickers
+	updates      <-chan trackUpdate // Inscrição no provider; nil sem Spotify
+	owner        bool               // Sessão autenticada com a chave do dono
+	loaded       bool               // Já recebeu a primeira atualização do provider
+	loadingFrame int                // Quadro das reticências da tela de carregamento
+	eqFrame      int                // Quadro do equalizador do widget vazio
+	lastInput    time.Time          // Última tecla, para o modo atração
+	attractIndex int                // Música da vez no modo atração
+
+	debug    bool           // Mostra o rodapé de debug (tecla d)
+	latency  time.Duration  // Duração da última busca no Spotify
+	source   trackSource    // De onde veio a música da última busca
+	lastErr  error          // Erro da última busca, se houve
+	failures int            // Buscas seguidas com erro, para o aviso de offline
+	caps     termCaps       // Capacidades detectadas do terminal
+	loc      *time.Location // Fuso da sessão (TZ), para o contador de hoje
+
+	prevTrack  *spotify.Track // Música de saída durante uma transição
+	prevArt    string         // Capa da música de saída, para a transição
+	transition int            // Quadro atual da transição; 0 quando parada
+
+	art    string // Última capa renderizada (ver artKey)
+	artErr error  // Erro ao renderizar art, se houve
+	artFor string // artKey da música de art; difere da atual enquanto carrega
+	fade   int    // Quadro do fade-in de art sobre o placeholder; 0 quando parado
+
+	frames   *frameCache // Última capa emoldurada; ponteiro para sobreviver às cópias do model
+	themeGen uint64      // Geração do tema com que art foi renderizada
+
+	footers         *footerCache // Linhas do rodapé entre quadros
+	widgets         *widgetCache // Último widget montado em volta da capa
+	placed          *placeCache  // Última tela posicionada
+	transitionBlend *blendCache  // Capas lidas da transição entre músicas
+	fadeBlend       *blendCache  // Capas lidas do fade-in sobre o placeholder
+
+	artMode artMode // Como a capa é desenhada nesta sessão
+	artSeed string  // Varia a arte gerada por sessão (ALBUMART_SESSION_SEED); vazio é determinístico
+	align   int     // Índice da posição do widget em alignments (tecla a)
+	focus   bool    // Modo foco: só a capa e uma linha de texto (tecla f)
+
+	progressStyle progressStyle // Estilo da barra de progresso (tecla b)
+
+	pinned  bool      // Congela o widget na música atual (tecla p)
+	pending *trackMsg // Última atualização recebida enquanto fixado
+
+	showRecent bool             // Mostra as músicas recentes em vez da atual (tecla h)
+	recent     []*spotify.Track // Cópia do histórico ao abrir a visão, da mais recente à mais antiga
+
+	showQR bool   // Mostra o QR code do link da música (tecla r)
+	qrFor  string // Link de qrCode
+	qrCode string // QR code renderizado; vazio sem link
+
+	out      io.Writer // Saída da sessão, para sequências fora do View (OSC 52)
+	notice   string    // Aviso temporário no rodapé (ex.: link copiado)
+	welcome  string    // Boas-vindas na tela; vazio depois de dispensadas
+	greeting string    // Saudação com o país de quem conectou (GEOIP_DB)
+
+	notify    notifyMode     // Aviso de troca de música (tecla n)
+	announced *spotify.Track // Última música tocando anunciada
+	flash     bool           // Borda invertida pelo aviso notifyFlash
+}
+
+const (
+	// pollInterval é o intervalo entre buscas da música atual.
+	pollInterval = 10 * time.Second
+
+	// watchersInterval é o intervalo de atualização do contador de sessões.
+	watchersInterval = 5 * time.Second
+
+	// Transição entre músicas: transitionFrames quadros de transitionInterval (~300ms).
+	transitionInterval = 30 * time.Millisecond
+	transitionFrames   = 10
+
+	// Fade-in da capa ao terminar de carregar: fadeFrames quadros de fadeInterval (~320ms).
+	fadeInterval = 40 * time.Millisecond
+	fadeFrames   = 8
+
+	// recentLimit é o máximo de músicas na visão de recentes.
+	recentLimit = 10
+
+	// loadingInterval é o intervalo entre quadros das reticências do carregamento.
+	loadingInterval = 400 * time.Millisecond
+)
+
+// newModel cria o model de uma sessão.
+func newModel(width, height int, updates <-chan trackUpdate, owner bool) model {
+	m := model{
+		width:           width,
+		height:          height,
+		updates:         updates,
+		owner:           owner,
+		artMode:         defaultArtMode,
+		align:           defaultAlignment,
+		notify:          defaultNotifyMode,
+		frames:          &frameCache{},
+		footers:         &footerCache{},
+		widgets:         &widgetCache{},
+		placed:          &placeCache{},
+		transitionBlend: &blendCache{},
+		fadeBlend:       &blendCache{},
+		themeGen:        styles().gen,
+		loc:             time.Local,
+		lastInput:       time.Now(),
+	}
+	if owner {
+		m.tickers[tickerWatchers] = ticker{interval: watchersInterval, running: true}
+	}
+	if m.loading() {
+		m.tickers[tickerLoading] = ticker{interval: loadingInterval, running: true}
+	}
+	// Toda sessão começa sem música: o equalizador roda até a primeira chegar
+	m.tickers[tickerEqualizer] = ticker{interval: eqInterval, running: true}
+	if attractInterval > 0 {
+		m.tickers[tickerAttract] = ticker{interval: attractInterval, running: true}
+	}
+	if welcomeText != "" {
+		m.welcome = welcomeText
+		m.tickers[tickerWelcome] = ticker{interval: welcomeDuration, running: true}
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
-		fetchTrack,
-		tickEvery(10*time.Second),
+		waitForTrack(m.updates),
+		m.tickers.next(tickerWatchers),
+		m.tickers.next(tickerLoading),
+		m.tickers.next(tickerEqualizer),
+		m.tickers.next(tickerAttract),
+		m.tickers.next(tickerWelcome),
 	)
 }
 
-func fetchTrack() tea.Msg {
-	if spotifyClient == nil {
-		return trackMsg{nil, nil}
+// waitForTrack espera a próxima atualização do provider.
+// Retorna nil quando não há inscrição ou ela foi cancelada.
+func waitForTrack(updates <-chan trackUpdate) tea.Cmd {
+	if updates == nil {
+		return nil
 	}
-
-	track, err := spotifyClient.GetCurrentlyPlaying()
-	if err != nil {
-		return trackMsg{nil, err}
-	}
-
-	if track == nil {
-		track, err = spotifyClient.GetRecentlyPlayed()
-		if track != nil {
-			track.IsPlaying = false
+	return func() tea.Msg {
+		u, ok := <-updates
+		if !ok {
+			return nil
 		}
+		return trackMsg(u)
 	}
-
-	return trackMsg{track, err}
 }
 
-func tickEvery(d time.Duration) tea.Cmd {
-	return tea.Tick(d, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+// queuePosition formata onde a música atual está na fila.
+// Com o total do contexto conhecido mostra "3 de 12" (ou "disco 2 · faixa 3"
+// em álbuns com vários discos, onde o total do álbum não corresponde ao
+// disco); caso contrário, apenas a próxima música ("próxima: <música>").
+// Singles (total 1) não têm posição a mostrar. Vazio se não há o que mostrar.
+func queuePosition(position, total, disc int, next *spotify.Track) string {
+	if disc > 1 && position > 0 {
+		return fmt.Sprintf("disco %d · faixa %d", disc, position)
+	}
+	if position > 0 && total > 1 && position <= total {
+		return fmt.Sprintf("%d de %d", position, total)
+	}
+	if next != nil && next.Name != "" {
+		return "próxima: " + next.Name
+	}
+	return ""
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.WindowSizeMsg:
+		// Alguns clientes reportam 0x0 durante o resize: mantém o último
+		// tamanho válido em vez de piscar a tela de carregamento
+		if (msg.Width == 0 || msg.Height == 0) && m.width > 0 && m.height > 0 {
+			return m, nil
+		}
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
 
 	case trackMsg:
-		if msg.err == nil && msg.track != nil {
-			m.currentTrack = msg.track
+		// Fixado, guarda só a mais recente para aplicar ao soltar
+		if m.pinned && m.loaded {
+			m.pending = &msg
+			return m, waitForTrack(m.updates)
+		}
+		var cmd tea.Cmd
+		m, cmd = m.applyTrack(msg)
+		return m, tea.Batch(cmd, waitForTrack(m.updates))
+
+	case noticeMsg:
+		return m.showNotice(string(msg), noticeDuration)
+
+	case artMsg:
+		// Capas de músicas que já saíram chegam tarde e são descartadas
+		if shown := m.shownTrack(); shown != nil && msg.key == artKey(shown) {
+			// Até aqui a tela mostrava o placeholder: a capa entra aos poucos sobre ele.
+			// Uma capa nova no meio de um fade recomeça do placeholder
+			fadeIn := m.artFor != msg.key
+			m.art, m.artErr, m.artFor = msg.art, msg.err, msg.key
+			if fadeIn {
+				m.fade = 1
+				return m, m.tickers.start(tickerFade, fadeInterval)
+			}
 		}
 		return m, nil
 
-	case tickMsg:
-		return m, fetchTrack
+	case tickerMsg:
+		if !m.tickers.accept(msg) {
+			return m, nil
+		}
+		switch msg.id {
+		case tickerWatchers:
+			// Nada a atualizar no model: o View relê activeSessions
+			return m, m.tickers.next(tickerWatchers)
+		case tickerTransition:
+			m.transition++
+			if m.transition >= transitionFrames {
+				m.tickers.stop(tickerTransition)
+				m.prevTrack = nil
+				m.prevArt = ""
+				m.transition = 0
+				return m, nil
+			}
+			return m, m.tickers.next(tickerTransition)
+		case tickerLoading:
+			if !m.loading() {
+				m.tickers.stop(tickerLoading)
+				return m, nil
+			}
+			m.loadingFrame++
+			return m, m.tickers.next(tickerLoading)
+		case tickerAttract:
+			if !m.attracting() {
+				return m, m.tickers.next(tickerAttract)
+			}
+			m.attractIndex++
+			return m, tea.Batch(m.tickers.next(tickerAttract), loadArt(m.shownTrack(), m.artMode, m.artSeed))
+		case tickerEqualizer:
+			if !m.eqAnimating() {
+				m.tickers.stop(tickerEqualizer)
+				return m, nil
+			}
+			m.eqFrame++
+			return m, m.tickers.next(tickerEqualizer)
+		case tickerProgress:
+			// Nada a atualizar no model: o View recalcula o progresso
+			return m, m.tickers.next(tickerProgress)
+		case tickerFade:
+			m.fade++
+			if m.fade >= fadeFrames {
+				m.fade = 0
+				m.tickers.stop(tickerFade)
+				return m, nil
+			}
+			return m, m.tickers.next(tickerFade)
+		case tickerFlash:
+			m.flash = false
+			m.tickers.stop(tickerFlash)
+			return m, nil
+		case tickerWelcome:
+			m.welcome = ""
+			m.tickers.stop(tickerWelcome)
+			return m, nil
+		case tickerNotice:
+			m.notice = ""
+			m.tickers.stop(tickerNotice)
+			return m, nil
+		}
+		return m, nil
 
 	case tea.KeyMsg:
+		// Uma tecla acorda o equalizador parado; o resto da tecla segue normal
+		if m.currentTrack == nil && !m.tickers.running(tickerEqualizer) {
+			eqCmd := m.tickers.start(tickerEqualizer, eqInterval)
+			next, cmd := m.Update(msg)
+			return next, tea.Batch(eqCmd, cmd)
+		}
+
+		wasAttracting := m.attracting()
+		m.lastInput = time.Now()
+
 		switch msg.String() {
 		case "ctrl+c", "q", "enter":
 			return m, tea.Quit
 		}
+
+		// Sem o tamanho da janela (alguns clientes mandam teclas antes do
+		// primeiro WindowSizeMsg) a tela ainda é o carregamento: as demais
+		// teclas mudariam um estado que ninguém vê, então só sair funciona
+		if m.width == 0 || m.height == 0 {
+			return m, nil
+		}
+
+		// Qualquer tecla (exceto sair) dispensa as boas-vindas antes do tempo
+		if m.welcome != "" {
+			m.welcome = ""
+			m.tickers.stop(tickerWelcome)
+			return m, nil
+		}
+
+		// Qualquer tecla só tira do modo atração, de volta à música atual
+		if wasAttracting {
+			return m, loadArt(m.currentTrack, m.artMode, m.artSeed)
+		}
+
+		if cmd, ok := m.playbackControl(msg.String()); ok {
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "d":
+			// O rodapé de debug expõe latência e erros da API: só para o dono
+			if m.owner {
+				m.debug = !m.debug
+			}
+		case "a":
+			m.align = (m.align + 1) % len(alignments)
+		case "f":
+			m.focus = !m.focus
+		case "b":
+			m.progressStyle = (m.progressStyle + 1) % numProgressStyles
+		case "p":
+			m.pinned = !m.pinned
+			if !m.pinned && m.pending != nil {
+				pending := *m.pending
+				m.pending = nil
+				// Aplica a atualização guardada sem outro waitForTrack:
+				// o do trackMsg guardado continua pendente
+				return m.applyTrack(pending)
+			}
+		case "n":
+			m.notify = (m.notify + 1) % numNotifyModes
+			return m.showNotice("aviso de troca: "+notifyModeNames[m.notify], noticeDuration)
+		case "u":
+			// Com a música pausada o polling fica espaçado; u busca de novo na hora
+			if provider != nil {
+				return m, func() tea.Msg {
+					provider.Refresh()
+					return nil
+				}
+			}
+		case "C":
+			// Só o dono: limpar o cache obriga todas as sessões a baixar as capas de novo
+			if m.owner {
+				albumart.ClearCache()
+				var noticeCmd tea.Cmd
+				m, noticeCmd = m.showNotice("cache de capas limpo", noticeDuration)
+				if track := m.shownTrack(); track != nil {
+					return m, tea.Batch(noticeCmd, loadArt(track, m.artMode, m.artSeed))
+				}
+				return m, noticeCmd
+			}
+		case "o":
+			return m.openTrack()
+		case "r":
+			m.showQR = !m.showQR
+			if m.showQR {
+				m = m.refreshQR()
+			}
+		case "h":
+			m.showRecent = !m.showRecent
+			if m.showRecent {
+				// A lista fica congelada enquanto a visão está aberta:
+				// o polling continua atualizando só a música atual
+				m.recent = recentTracks(m.history, recentLimit)
+			}
+		}
 	}
 	return m, nil
 }
 
-var (
-	// Cores principais
-	spotifyGreen = lipgloss.Color("#1DB954")
-	spotifyBlack = lipgloss.Color("#191414")
-	subtleGray   = lipgloss.Color("#535353")
-	lightGray    = lipgloss.Color("#B3B3B3")
-	white        = lipgloss.Color("#FFFFFF")
-
-	titleStyle = lipgloss.NewStyle().
-			Foreground(spotifyGreen).
-			Bold(true)
-
-	trackNameStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Bold(true)
-
-	artistStyle = lipgloss.NewStyle().
-			Foreground(lightGray)
-
-	albumStyle = lipgloss.NewStyle().
-			Foreground(subtleGray).
-			Italic(true)
-
-	footerStyle = lipgloss.NewStyle().
-			Foreground(subtleGray)
-
-	widgetBorder = lipgloss.NewStyle().
-			Border(lipgloss.DoubleBorder()).
-			BorderForeground(spotifyGreen).
-			Padding(1, 2)
-
-	emptyWidgetStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(subtleGray).
-				Padding(1, 2).
-				Foreground(subtleGray)
-)
+// applyTrack aplica uma atualização do provider ao model.
+func (m model) applyTrack(msg trackMsg) (model, tea.Cmd) {
+	m.loaded = true
+	m.latency = msg.latency
+	m.source = msg.source
+	m.lastErr = msg.err
+	m.history = msg.history
+	if msg.err != nil {
+		m.failures++
+	} else {
+		m.failures = 0
+	}
+	var cmd, artCmd, notifyCmd tea.Cmd
+	if msg.err == nil && msg.track != nil {
+		m, notifyCmd = m.announce(msg.track)
+		if m.currentTrack != nil && !sameSong(m.currentTrack, msg.track) {
+			// Uma troca no meio de outra reinicia a transição a partir da música atual
+			m.prevTrack = m.currentTrack
+			m.prevArt, _ = m.currentArt()
+			m.transition = 1
+			m.notice = ""
+			m.tickers.stop(tickerNotice)
+			cmd = m.tickers.start(tickerTransition, transitionInterval)
+		}
+		// Um tema recarregado (SIGHUP) muda as cores da capa: renderiza de novo
+		if gen := styles().gen; artKey(msg.track) != m.artFor || m.themeGen != gen {
+			m.themeGen = gen
+			artCmd = loadArt(msg.track, m.artMode, m.artSeed)
+		}
+		m.currentTrack = msg.track
+		m.queue = msg.queue
+		if m.showQR {
+			m = m.refreshQR()
+		}
+		m.fetchedAt = msg.at
+		m.tickers.stop(tickerEqualizer)
+
+		// A barra só precisa avançar enquanto a música toca
+		playing := msg.track.IsPlaying
+		if playing && !m.tickers.running(tickerProgress) {
+			cmd = tea.Batch(cmd, m.tickers.start(tickerProgress, progressInterval))
+		} else if !playing {
+			m.tickers.stop(tickerProgress)
+		}
+	}
+	return m, tea.Batch(cmd, artCmd, notifyCmd)
+}
 
 func (m model) View() string {
-	if m.width == 0 || m.height == 0 {
-		loadingStyle := lipgloss.NewStyle().
-			Foreground(spotifyGreen).
-			Bold(true)
-		return loadingStyle.Render("● Carregando...")
+	// As boas-vindas vêm antes até do carregamento: só precisam do tamanho da tela
+	if m.welcome != "" && m.width > 0 && m.height > 0 {
+		return m.renderWelcome()
+	}
+
+	if m.loading() {
+		dots := strings.Repeat(".", m.loadingFrame%4)
+		return styles().loading.Render(text.Loading + dots)
 	}
 
-	spotifyWidget := m.renderSpotifyWidget()
+	// Decidido antes do modo atração, que troca a música atual pela do histórico
+	hidden := m.widgetHidden()
 
-	footer := footerStyle.Render(" Pressione q ou Enter para sair ")
+	// No modo atração, o widget mostra a música da vez como se fosse a atual
+	if m.attracting() {
+		m.currentTrack = m.shownTrack()
+		m.queue = nil
+		m.prevTrack, m.transition = nil, 0
+	}
 
-	fullContent := lipgloss.JoinVertical(lipgloss.Center,
-		spotifyWidget,
-		footer,
-	)
+	if m.focus {
+		if hidden {
+			return m.place(m.renderAbout())
+		}
+		return m.place(m.renderFocus())
+	}
+
+	var spotifyWidget string
+	switch {
+	case m.showQR:
+		spotifyWidget = m.renderQRWidget()
+	case m.showRecent:
+		spotifyWidget = m.renderRecentWidget()
+	case hidden:
+		spotifyWidget = m.renderAbout()
+	default:
+		spotifyWidget = m.renderSpotifyWidget()
+	}
+
+	footer := styles().footer.Render(m.footerText())
+
+	sections := []string{spotifyWidget, footer}
+	if m.offline() {
+		sections = append([]string{m.offlineBanner()}, sections...)
+	}
+	if m.greeting != "" {
+		sections = append(sections, m.footerLine(m.greeting))
+	}
+	spark, today := m.footers.activity(m.history, time.Now(), m.loc)
+	if activity := activityLine(spark, m.width); activity != "" {
+		sections = append(sections, styles().footer.Render(activity))
+	}
+	if today != "" {
+		sections = append(sections, m.footerLine(today))
+	}
+	if m.pinned {
+		sections = append(sections, styles().footer.Render("📌"))
+	}
+	if controls := m.controlsLine(); controls != "" {
+		sections = append(sections, truncate(controls, m.width))
+	}
+	if m.notice != "" {
+		sections = append(sections, m.footerLine(m.notice))
+	}
+	if m.debug {
+		sections = append(sections, m.footerLine(m.debugLine()))
+	}
+
+	return m.place(lipgloss.JoinVertical(lipgloss.Center, sections...))
+}
 
-	contentHeight := lipgloss.Height(fullContent)
-	topPadding := (m.height - contentHeight) / 2
-	if topPadding < 0 {
-		topPadding = 0
+// footerLine renderiza s no estilo do rodapé, cortado em m.width: uma
+// linha mais larga que o terminal seria quebrada pelo place e desalinharia
+// o resto da tela.
+func (m model) footerLine(s string) string {
+	return styles().footer.Render(truncate(s, m.width))
+}
+
+// footerText monta o rodapé cabendo em m.width: a instrução completa,
+// depois a curta, e só então cortada. O contador do dono é o primeiro a sair.
+func (m model) footerText() string {
+	watching := int64(-1)
+	if m.owner {
+		watching = activeSessions.Load()
 	}
+	return m.footers.text(m.width, watching)
+}
+
+// buildFooterText é o footerText sem cache; watching < 0 omite o contador.
+func buildFooterText(width int, sessions int64) string {
+	var watching string
+	if sessions >= 0 {
+		watching = "· " + fmt.Sprintf(text.Watching, sessions) + " "
+	}
+
+	for _, candidate := range []string{
+		" " + text.Quit + " " + watching,
+		" " + text.QuitShort + " " + watching,
+		" " + text.QuitShort + " ",
+	} {
+		if lipgloss.Width(candidate) <= width {
+			return candidate
+		}
+	}
+	return truncate(text.QuitShort, width)
+}
+
+// loading informa se a sessão ainda está no carregamento inicial: sem o
+// tamanho do terminal ou, com o Spotify ligado, sem a primeira busca.
+func (m model) loading() bool {
+	return m.width == 0 || m.height == 0 || (m.updates != nil && !m.loaded)
+}
+
+// place posiciona content na tela conforme o alinhamento da sessão.
+func (m model) place(content string) string {
+	return m.placed.render(content, m.width, m.height, m.align)
+}
+
+// placeContent é o place sem cache.
+func placeContent(content string, width, height, alignIndex int) string {
+	align := alignments[alignIndex]
 
 	layout := lipgloss.NewStyle().
-		Width(m.width).
-		Height(m.height).
-		Align(lipgloss.Center, lipgloss.Top).
-		PaddingTop(topPadding)
+		Width(width).
+		Height(height).
+		Align(align.horizontal, lipgloss.Top).
+		PaddingTop(topPadding(align.vertical, lipgloss.Height(content), height))
+
+	return layout.Render(content)
+}
+
+// renderFocus renderiza o modo foco: capa sem moldura e uma linha
+// "música — artista", sem bordas, título ou rodapé.
+func (m model) renderFocus() string {
+	if m.currentTrack == nil {
+		return styles().artist.Render("Nenhuma música")
+	}
+
+	line := cmp.Or(m.currentTrack.Name, "Música desconhecida")
+	if m.currentTrack.Artist != "" {
+		line += " — " + m.currentTrack.Artist
+	}
+	line = styles().trackName.Render(truncate(line, m.width))
+
+	if m.width < artWidth {
+		return line
+	}
+
+	art, _ := m.currentArt()
+	return lipgloss.JoinVertical(lipgloss.Center, art, "", line)
+}
+
+// Dimensões do widget no layout completo.
+const (
+	artWidth  = 32 // Largura da capa em colunas
+	artHeight = 16 // Altura da capa em linhas
+	textWidth = 28 // Largura da coluna de texto, incluindo o padding
+)
+
+// widgetLayout define como capa e texto são dispostos no widget.
+type widgetLayout int
+
+const (
+	layoutHorizontal widgetLayout = iota // Capa à esquerda, texto à direita
+	layoutStacked                        // Capa acima do texto
+	layoutTextOnly                       // Apenas texto
+)
+
+// artistLine formata os artistas de track em width colunas.
+// Mostra todos quando cabem; senão o principal inteiro e o resto resumido
+// ("Artista feat. +2"); só em último caso corta o nome do principal.
+func artistLine(track *spotify.Track, width int) string {
+	artists := track.Artists
+	if len(artists) == 0 {
+		artists = []string{cmp.Or(track.Artist, "Artista desconhecido")}
+	}
+
+	if all := strings.Join(artists, ", "); lipgloss.Width(all) <= width {
+		return all
+	}
+	if len(artists) > 1 {
+		if short := fmt.Sprintf("%s feat. +%d", artists[0], len(artists)-1); lipgloss.Width(short) <= width {
+			return short
+		}
+	}
+	return truncate(artists[0], width)
+}
 
-	return layout.Render(fullContent)
+// chooseLayout escolhe o layout mais completo que cabe em width colunas.
+// Evita que o lipgloss quebre as linhas do widget em terminais estreitos.
+func chooseLayout(width int) widgetLayout {
+	chrome := styles().widget.GetHorizontalFrameSize()
+	artFrameWidth := artWidth + styles().artFrame.GetHorizontalFrameSize()
+
+	switch {
+	case width >= artFrameWidth+textWidth+chrome:
+		return layoutHorizontal
+	case width >= max(artFrameWidth, textWidth)+chrome:
+		return layoutStacked
+	default:
+		return layoutTextOnly
+	}
+}
+
+// truncate corta s em maxLen colunas, terminando com "...".
+// Conta largura de exibição (acentos, CJK, emoji), nunca quebrando
+// uma sequência multibyte no meio.
+func truncate(s string, maxLen int) string {
+	if lipgloss.Width(s) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return ansi.Truncate(s, max(maxLen, 0), "")
+	}
+	return ansi.Truncate(s, maxLen, "...")
 }
 
 func (m model) renderSpotifyWidget() string {
 	if m.currentTrack == nil {
 		content := lipgloss.JoinVertical(lipgloss.Center,
-			titleStyle.Render("♫ Spotify"),
+			styles().title.Render(widgetTitle(nil)),
 			"",
-			artistStyle.Render("Nenhuma música"),
+			styles().title.Render(m.eqView()),
+			"",
+			m.idleContent(),
 		)
-		return emptyWidgetStyle.Render(content)
+		return styles().emptyWidget.Render(content)
 	}
 
-	art, _ := albumart.RenderFromURL(m.currentTrack.ArtworkURL, 32, 16)
+	layout := chooseLayout(m.width)
 
-	artFrame := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(subtleGray).
-		Render(art)
+	colWidth := textWidth
+	if layout == layoutTextOnly {
+		colWidth = max(min(textWidth, m.width-styles().widget.GetHorizontalFrameSize()), 4)
+	}
+	textStyle := lipgloss.NewStyle().Width(colWidth)
+	if layout == layoutHorizontal {
+		textStyle = textStyle.PaddingLeft(2)
+	}
+	maxLen := colWidth - textStyle.GetHorizontalPadding()
+	wrap := m.wrapping(layout)
 
-	trackName := m.currentTrack.Name
-	if len(trackName) > 26 {
-		trackName = trackName[:23] + "..."
+	// Durante a transição, o texto antigo some esmaecido e o novo entra esmaecido.
+	// Pausada, a música fica esmaecida o tempo todo.
+	textTrack, faint := m.currentTrack, playbackStateOf(m.currentTrack) == statePaused
+	progress, transitioning := m.transitionProgress()
+	if transitioning {
+		faint = true
+		if progress < 0.5 {
+			textTrack = m.prevTrack
+		}
 	}
 
-	artist := m.currentTrack.Artist
-	if len(artist) > 26 {
-		artist = artist[:23] + "..."
+	// Itens sem metadados (arquivos locais, respostas incompletas) ainda mostram algo
+	var lines []string
+	// O título padrão só aparece no widget vazio; um template próprio aparece sempre
+	if titleTemplate != "" {
+		lines = append(lines, styles().title.Render(truncate(widgetTitle(textTrack), maxLen)), "")
 	}
+	lines = append(lines,
+		styles().trackName.Faint(faint).Render(fitText(cmp.Or(textTrack.Name, "Música desconhecida"), maxLen, wrap)),
+		styles().artist.Faint(faint).Render(artistText(textTrack, maxLen, wrap)),
+		styles().album.Faint(faint).Render(fitText(textTrack.Album, maxLen, wrap)),
+	)
+
+	if textTrack == m.currentTrack {
+		if progress := m.progressLine(maxLen); progress != "" {
+			lines = append(lines, "", styles().footer.Render(progress))
+		}
 
-	album := m.currentTrack.Album
-	if len(album) > 26 {
-		album = album[:23] + "..."
+		if !m.currentTrack.IsPlayable {
+			lines = append(lines, styles().footer.Render(truncate("indisponível na sua região", maxLen)))
+		}
+
+		if position := m.queuePosition(); position != "" {
+			lines = append(lines, "", styles().footer.Render(truncate(position, maxLen)))
+		}
+
+		// No contexto de álbum o nome já aparece na linha do álbum
+		if name := m.currentTrack.ContextName; name != "" && m.currentTrack.ContextType != "album" {
+			lines = append(lines, styles().footer.Render(truncate("de "+name, maxLen)))
+		}
 	}
 
-	textContent := lipgloss.JoinVertical(lipgloss.Left,
-		trackNameStyle.Render(trackName),
-		artistStyle.Render(artist),
-		albumStyle.Render(album),
-	)
+	text := textStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	if layout == layoutTextOnly {
+		return m.widgetStyle().Render(text)
+	}
+
+	art, artErr := m.currentArt()
+	if transitioning {
+		art = m.transitionBlend.blend(m.prevArt, art, progress)
+	}
+
+	artFrame := m.frames.render(art, artErr != nil)
+	return m.widgets.render(artFrame, text, layout, m.widgetStyle(), m.flash)
+}
+
+// openTrack tenta abrir o link da música atual no cliente (tecla o).
+//
+// O servidor não tem como abrir um navegador na máquina de quem conectou:
+// o melhor que dá para fazer é copiar o link para a área de transferência
+// via OSC 52 e mostrar o link como hyperlink OSC 8, clicável nos terminais
+// que suportam. Nos demais, o link aparece como texto para copiar à mão.
+func (m model) openTrack() (model, tea.Cmd) {
+	if m.currentTrack == nil || m.currentTrack.URL == "" {
+		return m.showNotice("sem link para esta música", noticeDuration)
+	}
+
+	url := m.currentTrack.URL
+	m, noticeCmd := m.showNotice("link: "+ansi.SetHyperlink(url)+url+ansi.ResetHyperlink(), linkNoticeDuration)
+
+	out := m.out
+	if out == nil {
+		return m, noticeCmd
+	}
+	return m, tea.Batch(noticeCmd, func() tea.Msg {
+		io.WriteString(out, ansi.SetSystemClipboard(url))
+		return nil
+	})
+}
+
+// renderRecentWidget renderiza a lista de músicas tocadas recentemente.
+func (m model) renderRecentWidget() string {
+	colWidth := max(min(artWidth+styles().artFrame.GetHorizontalFrameSize()+textWidth, m.width-styles().widget.GetHorizontalFrameSize()), 4)
+
+	lines := []string{styles().title.Render("♫ Tocadas recentemente"), ""}
+	if len(m.recent) == 0 {
+		lines = append(lines, styles().artist.Render("Nenhuma música nas últimas 24h"))
+	}
+	for _, t := range m.recent {
+		when := ""
+		if !t.PlayedAt.IsZero() {
+			when = " " + t.PlayedAt.Local().Format("15:04")
+		}
+		name := truncate(cmp.Or(t.Name, "Música desconhecida"), colWidth-lipgloss.Width(when))
+		lines = append(lines,
+			styles().trackName.Render(name)+styles().footer.Render(when),
+			styles().artist.Render(artistLine(t, colWidth)),
+		)
+	}
+
+	return styles().widget.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// recentTracks retorna até limit músicas de history (ordenado da mais
+// antiga à mais recente), da mais recente para a mais antiga.
+func recentTracks(history []*spotify.Track, limit int) []*spotify.Track {
+	n := min(len(history), limit)
+	recent := make([]*spotify.Track, n)
+	for i := range n {
+		recent[i] = history[len(history)-1-i]
+	}
+	return recent
+}
+
+// currentArt retorna a capa da música atual, ou o placeholder enquanto
+// ela ainda está sendo baixada. Durante o fade-in, a capa ainda está
+// misturada ao placeholder.
+func (m model) currentArt() (string, error) {
+	if m.currentTrack != nil && m.artFor == artKey(m.currentTrack) {
+		if m.fade > 0 {
+			return m.fadeBlend.blend(placeholderArt(m.artMode), m.art, float64(m.fade)/fadeFrames), m.artErr
+		}
+		return m.art, m.artErr
+	}
+	return placeholderArt(m.artMode), nil
+}
+
+// artKey identifica a capa de track: a URL e, para capas geradas
+// (sem URL), o nome e o álbum que servem de semente.
+func artKey(track *spotify.Track) string {
+	return track.ArtworkURL + "\x00" + track.Name + "\x00" + track.Album
+}
+
+// loadArt renderiza a capa de track fora do View: um download lento
+// (cache miss) atrasa só a capa, não a interface inteira.
+func loadArt(track *spotify.Track, mode artMode, seed string) tea.Cmd {
+	key := artKey(track)
+	return func() tea.Msg {
+		art, err := renderArt(track, mode, seed)
+		return artMsg{key: key, art: art, err: err}
+	}
+}
+
+// placeholderArt retorna a arte mostrada enquanto a capa carrega.
+func placeholderArt(mode artMode) string {
+	if mode == artSketch {
+		return styles().artist.Render(albumart.RenderSketchPlaceholder(albumart.PlaceholderLoading, artWidth, artHeight))
+	}
+	opts := artOptions
+	opts.Placeholder = styles().theme.placeholder()
+	return albumart.RenderPlaceholder(albumart.PlaceholderLoading, artWidth, artHeight, opts)
+}
+
+// renderArt renderiza a capa de track no modo dado, com as opções e o tema atuais.
+// Em caso de erro, a string retornada já é o placeholder (fallback visual).
+//
+// seed entra no hash da arte gerada para músicas sem capa: vazio, a arte
+// depende só da música; com o seed da sessão, cada visitante vê outra.
+func renderArt(track *spotify.Track, mode artMode, seed string) (string, error) {
+	if mode == artSketch {
+		art, err := albumart.RenderSketchFromURL(track.ArtworkURL, artWidth, artHeight)
+		if err != nil {
+			log.Debug("Falha ao renderizar capa", "url", track.ArtworkURL, "error", err)
+		}
+		return styles().artist.Render(art), err
+	}
+
+	opts := artOptions
+	opts.Placeholder = styles().theme.placeholder()
+	opts.CornerColor = styles().theme.artBorderRGBA()
+	opts.Background = hexRGBA(styles().theme.Background)
+
+	if track.ArtworkURL == "" && !artFallback {
+		return albumart.RenderGenerated(track.Name+"\x00"+track.Album+"\x00"+seed, artWidth, artHeight, opts), nil
+	}
 
-	textStyle := lipgloss.NewStyle().
-		Width(28).
-		PaddingLeft(2)
+	if artistCollage && len(track.ArtistIDs) > 1 && spotifyClient != nil {
+		collage, err := renderCollage(track, opts)
+		if err == nil {
+			return collage, nil
+		}
+		log.Debug("Falha ao montar colagem, usando a capa", "track", track.Name, "error", err)
+	}
+
+	art, err := albumart.RenderFromURLWithOptions(track.ArtworkURL, artWidth, artHeight, opts)
+	if err != nil {
+		log.Debug("Falha ao renderizar capa", "url", track.ArtworkURL, "error", err)
+	}
+	return art, err
+}
+
+// renderCollage monta a colagem com as fotos dos artistas de track.
+// Artistas sem foto ficam de fora; com menos de duas fotos, falha.
+func renderCollage(track *spotify.Track, opts albumart.Options) (string, error) {
+	images, err := spotifyClient.GetArtistImages(track.ArtistIDs[:min(len(track.ArtistIDs), 4)])
+	if err != nil {
+		return "", err
+	}
+
+	var urls []string
+	for _, u := range images {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return albumart.RenderCollageFromURLs(urls, artWidth, artHeight, opts)
+}
+
+// transitionProgress retorna o progresso (0 a 1) da transição entre músicas
+// e se há uma transição em andamento.
+func (m model) transitionProgress() (float64, bool) {
+	if m.transition == 0 || m.prevTrack == nil {
+		return 0, false
+	}
+	return float64(m.transition) / transitionFrames, true
+}
+
+// sameSong informa se a e b são a mesma música, ignorando o estado de reprodução.
+func sameSong(a, b *spotify.Track) bool {
+	return a.Name == b.Name && a.Artist == b.Artist && a.Album == b.Album
+}
+
+// debugLine resume o estado interno da sessão para o rodapé de debug.
+func (m model) debugLine() string {
+	line := "API: -"
+	if m.latency > 0 {
+		line = "API: " + m.latency.Round(time.Millisecond).String()
+	}
+	if m.loaded {
+		line += " · fonte: " + m.source.String()
+	}
+	if m.lastErr != nil {
+		line += " · erro: " + m.lastErr.Error()
+	}
+	return line + " · " + m.caps.String()
+}
+
+// frameCache guarda a última capa emoldurada de uma sessão.
+//
+// A capa é a maior parte do View e quase nunca muda entre quadros (o
+// progresso avança a cada segundo, a capa só na troca de música): sem o
+// cache, cada quadro repassaria milhares de células pelo lipgloss para
+// produzir a mesma string.
+type frameCache struct {
+	art    string
+	failed bool
+	gen    uint64 // Geração do tema da moldura (ver setTheme)
+	frame  string
+}
+
+// render retorna renderArtFrame(art, failed), reaproveitando o último
+// resultado se nada mudou. Um cache nil sempre renderiza.
+func (c *frameCache) render(art string, failed bool) string {
+	if c == nil {
+		return renderArtFrame(art, failed)
+	}
+	if gen := styles().gen; c.frame == "" || c.art != art || c.failed != failed || c.gen != gen {
+		c.art, c.failed, c.gen = art, failed, gen
+		c.frame = renderArtFrame(art, failed)
+	}
+	return c.frame
+}
+
+// blendCache guarda as duas capas de uma mistura (transição ou fade-in)
+// já lidas, para que cada quadro só calcule as cores do passo da vez.
+type blendCache struct {
+	blender *albumart.Blender
+}
+
+// blend retorna albumart.Blend(from, to, t), relendo as capas só quando
+// elas mudam. Um cache nil sempre relê.
+func (c *blendCache) blend(from, to string, t float64) string {
+	if c == nil {
+		return albumart.Blend(from, to, t)
+	}
+	if c.blender == nil || !c.blender.Of(from, to) {
+		c.blender = albumart.NewBlender(from, to)
+	}
+	return c.blender.Blend(t)
+}
+
+// widgetCache guarda o último widget montado com a capa emoldurada e o
+// texto. Entre dois segundos do progresso, nenhum dos dois muda, e o widget
+// inteiro não precisa passar de novo pelo lipgloss.
+type widgetCache struct {
+	artFrame string
+	text     string
+	layout   widgetLayout
+	flash    bool
+	gen      uint64
+	widget   string
+}
+
+// render junta artFrame e text conforme layout e os envolve em style,
+// reaproveitando o último resultado se nada mudou. style depende só do tema
+// e de flash. Um cache nil sempre monta.
+func (c *widgetCache) render(artFrame, text string, layout widgetLayout, style lipgloss.Style, flash bool) string {
+	if c != nil && c.widget != "" && c.artFrame == artFrame && c.text == text &&
+		c.layout == layout && c.flash == flash && c.gen == styles().gen {
+		return c.widget
+	}
 
-	content := lipgloss.JoinHorizontal(lipgloss.Center, artFrame, textStyle.Render(textContent))
+	var content string
+	if layout == layoutStacked {
+		content = lipgloss.JoinVertical(lipgloss.Center, artFrame, "", text)
+	} else {
+		content = lipgloss.JoinHorizontal(lipgloss.Center, artFrame, text)
+	}
+	widget := style.Render(content)
 
-	return widgetBorder.Render(content)
+	if c != nil {
+		c.artFrame, c.text, c.layout, c.flash, c.gen = artFrame, text, layout, flash, styles().gen
+		c.widget = widget
+	}
+	return widget
+}
+
+// placeCache guarda a última tela posicionada por place: com o mesmo
+// conteúdo e o mesmo tamanho, o View devolve a mesma string.
+type placeCache struct {
+	content       string
+	width, height int
+	align         int
+	placed        string
+}
+
+// render retorna placeContent(content, width, height, align), reaproveitando
+// o último resultado. Um cache nil sempre posiciona.
+func (c *placeCache) render(content string, width, height, align int) string {
+	if c == nil {
+		return placeContent(content, width, height, align)
+	}
+	if c.placed == "" || c.content != content || c.width != width || c.height != height || c.align != align {
+		c.content, c.width, c.height, c.align = content, width, height, align
+		c.placed = placeContent(content, width, height, align)
+	}
+	return c.placed
+}
+
+// footerCache guarda as linhas do rodapé entre quadros. O sparkline e o
+// contador de hoje só mudam com o histórico ou a virada do minuto, e o
+// texto de saída com a largura ou o número de sessões.
+type footerCache struct {
+	history []*spotify.Track
+	minute  time.Time
+	loc     *time.Location
+	spark   string
+	today   string
+	valid   bool
+
+	width    int
+	sessions int64
+	footer   string
+}
+
+// activity retorna o sparkline de atividade e o contador de hoje de history
+// em now, recalculados no máximo uma vez por minuto. Um cache nil sempre
+// recalcula.
+func (c *footerCache) activity(history []*spotify.Track, now time.Time, loc *time.Location) (spark, today string) {
+	if c == nil {
+		return sparkline(playTimes(history), now, activityWindow, activityBuckets),
+			todayText(playsToday(history, now, loc))
+	}
+	// Uma reprodução nova sempre chega com um histórico novo
+	minute := now.Truncate(time.Minute)
+	if !c.valid || !minute.Equal(c.minute) || c.loc != loc || !slices.Equal(c.history, history) {
+		c.history, c.minute, c.loc, c.valid = history, minute, loc, true
+		c.spark = sparkline(playTimes(history), now, activityWindow, activityBuckets)
+		c.today = todayText(playsToday(history, now, loc))
+	}
+	return c.spark, c.today
+}
+
+// text retorna buildFooterText(width, sessions), reaproveitando o último
+// resultado. Um cache nil sempre monta.
+func (c *footerCache) text(width int, sessions int64) string {
+	if c == nil {
+		return buildFooterText(width, sessions)
+	}
+	if c.footer == "" || c.width != width || c.sessions != sessions {
+		c.width, c.sessions = width, sessions
+		c.footer = buildFooterText(width, sessions)
+	}
+	return c.footer
+}
+
+// renderArtFrame envolve a capa na moldura do tema.
+// Se failed, marca o canto inferior direito da moldura com ✕ para
+// diferenciar uma capa que falhou de uma música que não tem capa.
+func renderArtFrame(art string, failed bool) string {
+	// Sem moldura não há onde marcar a falha
+	if !failed || styles().artFrame.GetBorderBottomSize() == 0 {
+		return styles().artFrame.Render(art)
+	}
+
+	frame := styles().artFrame.BorderBottom(false).Render(art)
+
+	border := styles().artFrame.GetBorderStyle()
+	fill := max(lipgloss.Width(frame)-4, 0)
+	bottom := border.BottomLeft + strings.Repeat(border.Bottom, fill) + "✕" + border.Bottom + border.BottomRight
+
+	borderStyle := lipgloss.NewStyle().Foreground(styles().artFrame.GetBorderBottomForeground())
+	return frame + "\n" + borderStyle.Render(bottom)
+}
+
+// queuePosition calcula o indicador de posição para a música atual.
+// A posição só é conhecida quando o contexto é o próprio álbum.
+func (m model) queuePosition() string {
+	var position, total, disc int
+	if m.currentTrack.ContextType == "album" {
+		position, total = m.currentTrack.TrackNumber, m.currentTrack.AlbumTracks
+		disc = m.currentTrack.DiscNumber
+	}
+
+	var next *spotify.Track
+	if m.queue != nil && len(m.queue.Next) > 0 {
+		next = m.queue.Next[0]
+	}
+
+	return queuePosition(position, total, disc, next)
 }
 
 func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	pty, _, _ := s.Pty()
-	m := model{
-		width:  pty.Window.Width,
-		height: pty.Window.Height,
+
+	var updates <-chan trackUpdate
+	if provider != nil {
+		var unsubscribe func()
+		updates, unsubscribe = provider.Subscribe()
+		go func() {
+			<-s.Context().Done()
+			unsubscribe()
+		}()
+	}
+
+	m := newModel(pty.Window.Width, pty.Window.Height, updates, isOwner(s))
+	m.out = s
+	m.caps = detectCaps(pty.Term, s.Environ())
+	m.greeting = greeting(s.RemoteAddr())
+	if m.welcome != "" {
+		log.Info("Boas-vindas exibidas", "user", s.User(), "remote", s.RemoteAddr(), "owner", m.owner)
+	}
+	m.loc = sessionLocation(s.Environ())
+	if autoArtMode {
+		m.artMode = m.caps.artMode()
+	}
+	if sessionArtSeed {
+		// O horário da conexão basta: o objetivo é variedade, não segredo
+		m.artSeed = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	var opts []tea.ProgramOption
+	if altScreen == altScreenAlways || altScreen == altScreenAuto && m.caps.altScreen {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	return m, opts
+}
+
+// defaultHostKeyPath é onde fica a chave do servidor SSH (HOST_KEY_PATH).
+const defaultHostKeyPath = ".ssh/id_ed25519"
+
+// checkHostKey verifica a chave do servidor antes de criar o servidor.
+//
+// Com generate ligado, o wish cria uma chave nova se path não existir.
+// Desligado (HOST_KEY_GENERATE=false, ex.: para garantir que a identidade
+// do servidor nunca mude sem querer), a ausência do arquivo é um erro, e
+// a mensagem diz qual caminho falta e como gerar a chave.
+func checkHostKey(path string, generate bool) error {
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrNotExist) && generate:
+		log.Info("Chave do servidor não encontrada, uma nova será gerada", "path", path)
+		return nil
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%s não existe e HOST_KEY_GENERATE=false; gere com: ssh-keygen -t ed25519 -N \"\" -f %s", path, path)
+	default:
+		return fmt.Errorf("lendo %s: %w", path, err)
+	}
+}
+
+// defaultStartupWindow é por quanto tempo o primeiro token é tentado no boot.
+const defaultStartupWindow = time.Minute
+
+// tokenRetryDelay é a primeira espera entre tentativas de waitForToken.
+var tokenRetryDelay = time.Second
+
+// waitForToken tenta obter o primeiro access token por até window, com
+// backoff exponencial. Em containers a rede e o DNS podem ainda não estar
+// prontos no boot; sem as novas tentativas, o widget ficaria vazio até o
+// próximo ciclo do provider. Roda em segundo plano: o servidor SSH sobe
+// de qualquer forma. Retorna se conseguiu.
+func waitForToken(client *spotify.Client, window time.Duration) bool {
+	deadline := time.Now().Add(window)
+	delay := tokenRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := client.CheckAuth()
+		if err == nil {
+			if attempt > 1 {
+				log.Info("Token do Spotify obtido", "attempts", attempt)
+			}
+			return true
+		}
+
+		if time.Now().Add(delay).After(deadline) {
+			log.Error("Desistindo de obter o token do Spotify", "attempts", attempt, "error", err)
+			return false
+		}
+		log.Warn("Falha ao obter token do Spotify, tentando de novo", "attempt", attempt, "retry_in", delay, "error", err)
+		time.Sleep(delay)
+		delay = min(delay*2, 15*time.Second)
+	}
+}
+
+// warnMissingScopes avisa, uma única vez, quais scopes parecem faltar no
+// refresh token. Sem eles os fallbacks falham a cada ciclo de polling sem
+// explicar o motivo.
+func warnMissingScopes(client *spotify.Client) {
+	missing, err := client.CheckScopes()
+	if err != nil {
+		log.Warn("Não foi possível verificar os scopes do Spotify", "error", err)
+		return
+	}
+	if len(missing) > 0 {
+		log.Warn("Refresh token sem scopes necessários; autorize de novo com eles",
+			"missing", strings.Join(missing, " "))
 	}
-	return m, []tea.ProgramOption{tea.WithAltScreen()}
 }
 
 func main() {
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if level, err := log.ParseLevel(v); err == nil {
+			log.SetLevel(level)
+		} else {
+			log.Warn("LOG_LEVEL desconhecido, usando info", "value", v)
+		}
+	}
+	if os.Getenv("LOG_FORMAT") == "json" {
+		log.SetFormatter(log.JSONFormatter)
+	}
+
+	// Proxy explícito para a API e as capas; sem ele valem HTTP(S)_PROXY
+	var proxyURL *url.URL
+	if v := os.Getenv("OUTBOUND_PROXY"); v != "" {
+		u, err := url.Parse(v)
+		if err != nil || u.Host == "" {
+			log.Error("OUTBOUND_PROXY inválido", "value", v, "error", err)
+			os.Exit(1)
+		}
+		proxyURL = u
+		albumart.SetProxy(u)
+	}
+
+	// Lidos antes do cliente do Spotify: a goroutine de boot consulta os dois
+	titleTemplate = os.Getenv("TITLE_TEMPLATE")
+	if name := os.Getenv("OWNER_NAME"); name != "" {
+		ownerName.Store(name)
+	}
+
 	clientID := os.Getenv("SPOTIFY_CLIENT_ID")
 	clientSecret := os.Getenv("SPOTIFY_CLIENT_SECRET")
 	refreshToken := os.Getenv("SPOTIFY_REFRESH_TOKEN")
 
 	if clientID != "" && clientSecret != "" && refreshToken != "" {
-		spotifyClient = spotify.NewClient(clientID, clientSecret, refreshToken)
+		var clientOpts []spotify.Option
+		if v := os.Getenv("SPOTIFY_TIMEOUT"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				clientOpts = append(clientOpts, spotify.WithTimeout(d))
+			} else {
+				log.Warn("SPOTIFY_TIMEOUT inválido, usando o padrão", "value", v)
+			}
+		}
+
+		if v := os.Getenv("MAX_FIELD_LENGTH"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				spotify.SetMaxFieldLength(n)
+			} else {
+				log.Warn("MAX_FIELD_LENGTH inválido, usando o padrão", "value", v, "default", spotify.DefaultMaxFieldLength)
+			}
+		}
+
+		if proxyURL != nil {
+			clientOpts = append(clientOpts, spotify.WithProxy(proxyURL))
+		}
+		if os.Getenv("SPOTIFY_RECENT_FALLBACK") == "false" {
+			clientOpts = append(clientOpts, spotify.WithRecentlyPlayedFallback(false))
+		}
+		if path := os.Getenv("SPOTIFY_TOKEN_CACHE"); path != "" {
+			clientOpts = append(clientOpts, spotify.WithTokenCache(path))
+		}
+
+		spotifyClient = spotify.NewClient(clientID, clientSecret, refreshToken, clientOpts...)
+		cfg := ProviderConfig{
+			AlwaysOn:        os.Getenv("POLL_ALWAYS") == "true",
+			FallbackOnError: os.Getenv("POLL_FALLBACK_ON_ERROR") == "true",
+		}
+		if v := os.Getenv("POLL_INTERVAL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				log.Warn("POLL_INTERVAL inválido, usando o padrão", "value", v, "default", pollInterval)
+			}
+			cfg.Interval = d
+		}
+		if v := os.Getenv("POLL_MAX_BACKOFF"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				log.Warn("POLL_MAX_BACKOFF inválido, usando o padrão", "value", v, "default", defaultMaxBackoff)
+			}
+			cfg.MaxBackoff = d
+		}
+		if v := os.Getenv("POLL_PAUSED_INTERVAL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				log.Warn("POLL_PAUSED_INTERVAL inválido, usando o padrão", "value", v, "default", defaultPausedInterval)
+			}
+			cfg.PausedInterval = d
+		}
+		startupWindow := defaultStartupWindow
+		if v := os.Getenv("SPOTIFY_STARTUP_WINDOW"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+				startupWindow = d
+			} else {
+				log.Warn("SPOTIFY_STARTUP_WINDOW inválido, usando o padrão", "value", v, "default", defaultStartupWindow)
+			}
+		}
+		provider = StartProvider(spotifyClient, cfg)
+		go func(p *Provider) {
+			if waitForToken(spotifyClient, startupWindow) {
+				// A primeira busca do provider provavelmente falhou junto e
+				// está em backoff; sem isso o widget esperaria minutos
+				p.Retry()
+				warnMissingScopes(spotifyClient)
+				loadOwnerName(spotifyClient)
+			}
+		}(provider)
+		onShutdown("provider", func(context.Context) error {
+			provider.Stop()
+			return nil
+		})
 		log.Info("Spotify client initialized")
 	} else {
 		log.Warn("Spotify credentials not found, widget disabled")
 	}
 
-	s, err := wish.NewServer(
+	artOptions.Grayscale = os.Getenv("ALBUMART_GRAYSCALE") == "true"
+	sessionArtSeed = os.Getenv("ALBUMART_SESSION_SEED") == "true"
+	if v := os.Getenv("ALBUMART_DENSITY"); v != "" {
+		if d, err := strconv.ParseFloat(v, 64); err == nil && d > 0 && d <= 1 {
+			artOptions.Density = d
+		} else {
+			log.Warn("ALBUMART_DENSITY deve estar entre 0 e 1, usando 1", "value", v)
+		}
+	}
+
+	if v := os.Getenv("ALBUMART_COLORS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 8 {
+			artOptions.Colors = n
+		} else {
+			log.Warn("ALBUMART_COLORS deve ser pelo menos 8, usando true color", "value", v)
+		}
+	}
+
+	if v := os.Getenv("ALBUMART_CORNER_RADIUS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			artOptions.CornerRadius = n
+		} else {
+			log.Warn("ALBUMART_CORNER_RADIUS inválido, usando cantos retos", "value", v)
+		}
+	}
+
+	if v := os.Getenv("ALBUMART_PORTRAIT"); v != "" {
+		if fit, ok := albumart.ParseFit(v); ok {
+			artOptions.Fit = fit
+		} else {
+			log.Warn("ALBUMART_PORTRAIT desconhecido, esticando a capa", "value", v)
+		}
+	}
+
+	if v := os.Getenv("ALBUMART_CELL_RATIO"); v != "" {
+		if ratio, err := strconv.ParseFloat(v, 64); err == nil && ratio > 0 {
+			artOptions.CellRatio = ratio
+		} else {
+			log.Warn("ALBUMART_CELL_RATIO deve ser positivo, ignorando", "value", v)
+		}
+	}
+
+	if v := os.Getenv("ALBUMART_SCALER"); v != "" {
+		if interp, ok := albumart.ParseInterpolation(v); ok {
+			artOptions.Interpolation = interp
+		} else {
+			log.Warn("ALBUMART_SCALER desconhecido, usando catmullrom", "value", v)
+		}
+	}
+
+	artistCollage = os.Getenv("ALBUMART_COLLAGE") == "true"
+
+	if v := os.Getenv("ALBUMART_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			albumart.SetRenderWorkers(n)
+		} else {
+			log.Warn("ALBUMART_WORKERS deve ser positivo, usando um por CPU", "value", v)
+		}
+	}
+
+	// Tempos de cada fase da capa, para investigar renderizações lentas
+	if os.Getenv("ALBUMART_TIMINGS") == "true" {
+		albumart.SetTimingsHook(func(url string, t albumart.Timings) {
+			log.Info("Tempos da capa", "url", url, "download", t.Download, "decode", t.Decode,
+				"queue", t.Queue, "resize", t.Resize, "encode", t.Encode, "total", t.Total())
+		})
+	}
+
+	if v := os.Getenv("ALBUMART_FALLBACK"); v != "" {
+		if err := albumart.LoadFallbackImage(v); err != nil {
+			log.Warn("ALBUMART_FALLBACK não pôde ser carregado, usando o placeholder padrão", "value", v, "error", err)
+		} else {
+			artFallback = true
+		}
+	}
+
+	if v := os.Getenv("TEXT_WRAP"); v != "" {
+		if mode, ok := parseTextWrap(v); ok {
+			textWrap = mode
+		} else {
+			log.Warn("TEXT_WRAP desconhecido, cortando o texto", "value", v)
+		}
+	}
+
+	if v := os.Getenv("OFFLINE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offlineThreshold = n
+		} else {
+			log.Warn("OFFLINE_THRESHOLD inválido, usando o padrão", "value", v, "default", defaultOfflineThreshold)
+		}
+	}
+
+	if v := os.Getenv("ATTRACT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			attractInterval = d
+		} else {
+			log.Warn("ATTRACT_INTERVAL inválido, modo atração desligado", "value", v)
+		}
+	}
+
+	if name := os.Getenv("LOCALE"); name != "" {
+		if l, ok := locales[name]; ok {
+			text = l
+		} else {
+			log.Warn("LOCALE desconhecido, usando o padrão", "locale", name, "default", defaultLocale)
+		}
+	}
+
+	switch {
+	case os.Getenv("WELCOME_TEXT") != "":
+		welcomeText = os.Getenv("WELCOME_TEXT")
+	case os.Getenv("WELCOME") == "true":
+		welcomeText = text.Welcome
+	}
+	if v := os.Getenv("WELCOME_DURATION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			welcomeDuration = d
+		} else {
+			log.Warn("WELCOME_DURATION inválido, usando o padrão", "value", v, "default", defaultWelcomeDuration)
+		}
+	}
+
+	switch v := os.Getenv("ALT_SCREEN"); v {
+	case "", "auto":
+	case "always":
+		altScreen = altScreenAlways
+	case "never":
+		altScreen = altScreenNever
+	default:
+		log.Warn("ALT_SCREEN desconhecido, usando auto", "value", v)
+	}
+
+	if name := os.Getenv("TRACK_NOTIFY"); name != "" {
+		if mode, ok := parseNotifyMode(name); ok {
+			defaultNotifyMode = mode
+		} else {
+			log.Warn("TRACK_NOTIFY desconhecido, usando off", "mode", name)
+		}
+	}
+
+	if path := os.Getenv("GEOIP_DB"); path != "" {
+		db, err := loadGeoDB(path)
+		if err != nil {
+			log.Warn("Não foi possível carregar a base GeoIP, saudação desligada", "path", path, "error", err)
+		} else {
+			geoDB = db
+			log.Info("Base GeoIP carregada", "ranges", len(db))
+		}
+	}
+
+	if path := os.Getenv("IDLE_LOGO_FILE"); path != "" {
+		if logo, err := loadLogo(path); err != nil {
+			log.Warn("Não foi possível carregar o logo, usando o texto padrão", "path", path, "error", err)
+		} else {
+			idleLogo = logo
+		}
+	}
+
+	playingOnly = os.Getenv("PLAYING_ONLY") == "true"
+	if path := os.Getenv("ABOUT_FILE"); path != "" {
+		if about, err := loadLogo(path); err != nil {
+			log.Warn("Não foi possível carregar o painel sobre, usando o padrão", "path", path, "error", err)
+		} else {
+			aboutLines = about
+		}
+	}
+
+	if name := os.Getenv("WIDGET_ALIGN"); name != "" {
+		if i, ok := alignmentIndex(name); ok {
+			defaultAlignment = i
+		} else {
+			log.Warn("WIDGET_ALIGN desconhecido, usando center", "align", name)
+		}
+	}
+
+	switch mode := os.Getenv("ALBUMART_MODE"); mode {
+	case "", "blocks":
+	case "sketch":
+		defaultArtMode = artSketch
+	case "auto":
+		autoArtMode = true
+	default:
+		log.Warn("ALBUMART_MODE desconhecido, usando blocks", "mode", mode)
+	}
+
+	if name := os.Getenv("THEME"); name != "" {
+		if t, ok := themes[name]; ok {
+			setTheme(t)
+		} else {
+			log.Warn("Tema desconhecido, usando o padrão", "theme", name, "default", defaultTheme)
+		}
+	}
+
+	// THEME_FILE tem precedência sobre THEME e é relido a cada SIGHUP
+	if path := os.Getenv("THEME_FILE"); path != "" {
+		if t, err := loadThemeFile(path); err != nil {
+			log.Error("Tema inválido, mantendo o atual", "path", path, "error", err)
+		} else {
+			setTheme(t)
+		}
+		watchThemeFile(path)
+	}
+
+	loadOwnerKey()
+	if err := loadAllowedKeys(); err != nil {
+		log.Error("Erro ao carregar allowlist", "error", err)
+		os.Exit(1)
+	}
+
+	hostKeyPath := cmp.Or(os.Getenv("HOST_KEY_PATH"), defaultHostKeyPath)
+	if err := checkHostKey(hostKeyPath, os.Getenv("HOST_KEY_GENERATE") != "false"); err != nil {
+		log.Error("Chave do servidor indisponível", "error", err)
+		os.Exit(1)
+	}
+
+	opts := []ssh.Option{
 		wish.WithAddress(net.JoinHostPort(host, port)),
-		wish.WithHostKeyPath(".ssh/id_ed25519"),
+		wish.WithHostKeyPath(hostKeyPath),
 		wish.WithMiddleware(
-			bubbletea.Middleware(teaHandler),
+			safeMiddleware(),
+			restrictAccess,
+			countSessions,
 		),
-	)
+	}
+	if ownerKey != nil || allowedKeys != nil {
+		opts = append(opts, identityOptions()...)
+	}
+
+	s, err := wish.NewServer(opts...)
 	if err != nil {
 		log.Error("Erro ao criar servidor", "error", err)
 		os.Exit(1)
@@ -252,21 +1641,57 @@ func main() {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	log.Info("Servidor SSH iniciado", "host", host, "port", port)
+	ln, boundPort, err := listenSSH(host, port)
+	if err != nil {
+		log.Error("Erro ao abrir porta", "error", err)
+		os.Exit(1)
+	}
+	sshUp.Store(true)
+
+	log.Info("Servidor SSH iniciado", "host", host, "port", boundPort)
 	go func() {
-		if err := s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+		defer sshUp.Store(false)
+		if err := s.Serve(ln); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
 			log.Error("Erro no servidor", "error", err)
 			done <- nil
 		}
 	}()
 
+	// Socket Unix opcional que entrega a música atual em JSON a cada conexão
+	if path := os.Getenv("TRACK_SOCKET"); path != "" && provider != nil {
+		ln, err := listenTrackSocket(path, provider)
+		if err != nil {
+			log.Error("Erro ao abrir o socket da música atual", "path", path, "error", err)
+		} else {
+			log.Info("Socket da música atual aberto", "path", path)
+			onShutdown("track-socket", closeTrackSocket(ln, path))
+		}
+	}
+
+	// Servidor HTTP opcional (healthchecks, JSON e SSE da música atual)
+	if addr := os.Getenv("HTTP_ADDR"); addr != "" {
+		httpServer := newHTTPServer(addr, provider, spotifyClient)
+		log.Info("Servidor HTTP iniciado", "addr", addr)
+		go func() {
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("Erro no servidor HTTP", "error", err)
+			}
+		}()
+		onShutdown("http", httpServer.Shutdown)
+	}
+
 	<-done
 	log.Info("Encerrando servidor...")
 
+	// Downloads de capas não têm prazo: aborta antes de esperar as sessões
+	albumart.CancelDownloads()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
 		log.Error("Erro ao encerrar servidor", "error", err)
 	}
+
+	runClosers(ctx)
 }
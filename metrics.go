@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// handleMetrics expõe métricas no formato texto do Prometheus.
+// As métricas do polling só aparecem com o Spotify configurado (p != nil).
+func handleMetrics(p *Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		writeGauge(w, "ssh_portfolio_sessions_active", "Sessões SSH abertas.", float64(activeSessions.Load()))
+
+		if p != nil {
+			failures, delay := p.Backoff()
+			writeGauge(w, "ssh_portfolio_poll_consecutive_failures", "Buscas seguidas no Spotify que falharam.", float64(failures))
+			writeGauge(w, "ssh_portfolio_poll_delay_seconds", "Espera atual até a próxima busca, incluindo o backoff.", delay.Seconds())
+		}
+	}
+}
+
+// writeGauge escreve uma métrica do tipo gauge com seu HELP e TYPE.
+func writeGauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsBackoff(t *testing.T) {
+	p := &Provider{cfg: ProviderConfig{Interval: 10 * time.Second, MaxBackoff: 5 * time.Minute}}
+	p.backoff(errors.New("503"))
+	p.backoff(errors.New("503"))
+
+	rec := httptest.NewRecorder()
+	handleMetrics(p)(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, want := range []string{
+		"ssh_portfolio_poll_consecutive_failures 2\n",
+		"ssh_portfolio_poll_delay_seconds 40\n",
+		"# TYPE ssh_portfolio_poll_delay_seconds gauge\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestMetricsWithoutSpotify(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleMetrics(nil)(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if body := rec.Body.String(); strings.Contains(body, "ssh_portfolio_poll_") {
+		t.Errorf("polling metrics without a provider:\n%s", body)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// notifyMode é como a sessão avisa que a música mudou.
+type notifyMode int
+
+const (
+	notifyOff   notifyMode = iota // Sem aviso (padrão)
+	notifyBell                    // Campainha do terminal (\a)
+	notifyFlash                   // Borda do widget invertida por um instante
+	numNotifyModes
+)
+
+// notifyModeNames são os nomes aceitos por TRACK_NOTIFY, na ordem de notifyMode.
+var notifyModeNames = [numNotifyModes]string{"off", "bell", "flash"}
+
+// defaultNotifyMode é o modo de aviso das novas sessões (TRACK_NOTIFY).
+var defaultNotifyMode = notifyOff
+
+// flashDuration é quanto tempo a borda fica invertida no modo flash.
+const flashDuration = 150 * time.Millisecond
+
+// parseNotifyMode converte um nome de TRACK_NOTIFY em notifyMode.
+func parseNotifyMode(name string) (notifyMode, bool) {
+	for i, n := range notifyModeNames {
+		if n == name {
+			return notifyMode(i), true
+		}
+	}
+	return notifyOff, false
+}
+
+// trackChanged informa se track deve ser anunciada, dado o último anúncio.
+//
+// Só músicas tocando contam: quando a reprodução para, o fallback troca a
+// atual pela última tocada e, ao voltar, troca de novo. Comparando com a
+// última música anunciada (e não com a exibida), essas idas e vindas não
+// disparam o aviso.
+func trackChanged(announced, track *spotify.Track) bool {
+	if track == nil || playbackStateOf(track) != statePlaying {
+		return false
+	}
+	return announced == nil || !sameSong(announced, track)
+}
+
+// announce registra track como a música tocando e, se ela mudou, emite o
+// aviso configurado. A primeira música da sessão não é anunciada.
+func (m model) announce(track *spotify.Track) (model, tea.Cmd) {
+	if !trackChanged(m.announced, track) {
+		return m, nil
+	}
+	first := m.currentTrack == nil
+	m.announced = track
+	if first {
+		return m, nil
+	}
+
+	switch m.notify {
+	case notifyBell:
+		out := m.out
+		if out == nil {
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			io.WriteString(out, "\a")
+			return nil
+		}
+	case notifyFlash:
+		m.flash = true
+		return m, m.tickers.start(tickerFlash, flashDuration)
+	}
+	return m, nil
+}
+
+// widgetStyle retorna o estilo do widget, com a borda invertida durante o flash.
+func (m model) widgetStyle() lipgloss.Style {
+	if !m.flash {
+		return styles().widget
+	}
+	return styles().widget.
+		BorderForeground(styles().theme.Background).
+		BorderBackground(styles().theme.Primary)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+func TestTrackChanged(t *testing.T) {
+	song := &spotify.Track{Name: "Faixa", Artist: "Artista", Album: "Álbum", IsPlaying: true}
+	// Track não tem ID: a comparação é por nome, artista e álbum, o que
+	// também serve para arquivos locais, que só têm o nome
+	local := &spotify.Track{Name: "demo.mp3", IsPlaying: true}
+
+	tests := []struct {
+		name             string
+		announced, track *spotify.Track
+		want             bool
+	}{
+		{"nil and nil", nil, nil, false},
+		{"nothing announced yet", nil, song, true},
+		{"same song", song, &spotify.Track{Name: "Faixa", Artist: "Artista", Album: "Álbum", IsPlaying: true}, false},
+		{"same song, new position", song, &spotify.Track{Name: "Faixa", Artist: "Artista", Album: "Álbum", IsPlaying: true, ProgressMs: 90000}, false},
+		{"different song", song, &spotify.Track{Name: "Outra", Artist: "Artista", Album: "Álbum", IsPlaying: true}, true},
+		{"same name, other album", song, &spotify.Track{Name: "Faixa", Artist: "Artista", Album: "Ao Vivo", IsPlaying: true}, true},
+		{"local file", song, local, true},
+		{"same local file", local, &spotify.Track{Name: "demo.mp3", IsPlaying: true}, false},
+		{"other local file", local, &spotify.Track{Name: "demo2.mp3", IsPlaying: true}, true},
+		// Pausado ou vindo do histórico não é uma troca de música
+		{"paused", song, &spotify.Track{Name: "Outra"}, false},
+		{"recently played", song, &spotify.Track{Name: "Outra", PlayedAt: time.Now()}, false},
+		{"announced, now nil", song, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trackChanged(tt.announced, tt.track); got != tt.want {
+				t.Errorf("trackChanged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+// defaultOfflineThreshold é quantas buscas seguidas precisam falhar para
+// o widget avisar que o Spotify está fora (OFFLINE_THRESHOLD).
+const defaultOfflineThreshold = 3
+
+// offlineThreshold é o limite em uso; 0 desliga o aviso.
+var offlineThreshold = defaultOfflineThreshold
+
+// offline informa se as últimas buscas falharam o bastante para o widget
+// mostrar o aviso em vez de exibir dados velhos como se fossem atuais.
+// Uma busca bem-sucedida zera m.failures e o aviso some sozinho.
+func (m model) offline() bool {
+	return offlineThreshold > 0 && m.failures >= offlineThreshold
+}
+
+// offlineBanner é o aviso acima do widget, cortado na largura da tela.
+// Com uma música conhecida, diz que ela continua aparecendo embaixo.
+func (m model) offlineBanner() string {
+	msg := text.Offline
+	if m.currentTrack != nil {
+		msg = text.OfflineLast
+	}
+	width := max(m.width-styles().banner.GetHorizontalFrameSize(), 1)
+	return styles().banner.Render(truncate(msg, width))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ssh-portfolio/spotify"
+)
+
+func TestOfflineBanner(t *testing.T) {
+	m := newModel(120, 40, nil, false)
+	track := &spotify.Track{Name: "Song", Artist: "Artist"}
+	m, _ = m.applyTrack(trackMsg{track: track})
+
+	fail := trackMsg{track: track, err: errors.New("503")}
+	for i := 1; i < offlineThreshold; i++ {
+		m, _ = m.applyTrack(fail)
+		if m.offline() {
+			t.Fatalf("offline after %d failures, threshold is %d", i, offlineThreshold)
+		}
+	}
+	m, _ = m.applyTrack(fail)
+	if !m.offline() {
+		t.Fatalf("not offline after %d failures", offlineThreshold)
+	}
+	view := m.View()
+	if !strings.Contains(view, text.OfflineLast) {
+		t.Error("offline view has no banner")
+	}
+	if !strings.Contains(view, "Song") {
+		t.Error("offline view dropped the last known track")
+	}
+
+	m, _ = m.applyTrack(trackMsg{track: track})
+	if m.offline() || strings.Contains(m.View(), text.OfflineLast) {
+		t.Error("banner still showing after a successful fetch")
+	}
+}
+
+func TestOfflineThresholdDisabled(t *testing.T) {
+	prev := offlineThreshold
+	offlineThreshold = 0
+	t.Cleanup(func() { offlineThreshold = prev })
+
+	m := newModel(120, 40, nil, false)
+	for range 10 {
+		m, _ = m.applyTrack(trackMsg{err: errors.New("503")})
+	}
+	if m.offline() {
+		t.Error("offline with OFFLINE_THRESHOLD=0")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+var (
+	// playingOnly esconde o widget quando nada está tocando (PLAYING_ONLY),
+	// mostrando o painel "sobre" no lugar. Como a tela é redesenhada a cada
+	// busca, o widget volta assim que a próxima busca vê a música tocando.
+	playingOnly bool
+
+	// aboutLines é o texto do painel "sobre" (ABOUT_FILE), uma linha por
+	// elemento. Vazio usa o logo do widget vazio ou, sem ele, text.About.
+	aboutLines []string
+)
+
+// widgetHidden informa se o widget deve dar lugar ao painel "sobre": com
+// PLAYING_ONLY, sem música ou com ela pausada (inclusive o fallback do
+// histórico, que nunca está tocando).
+func (m model) widgetHidden() bool {
+	return playingOnly && (m.currentTrack == nil || !m.currentTrack.IsPlaying)
+}
+
+// renderAbout renderiza o painel "sobre" com a moldura do widget vazio.
+func (m model) renderAbout() string {
+	lines := aboutLines
+	if len(lines) == 0 {
+		lines = idleLogo
+	}
+	if len(lines) == 0 || m.idleWidth() < 8 {
+		return styles().emptyWidget.Render(styles().artist.Render(truncate(text.About, max(m.idleWidth(), 1))))
+	}
+	return styles().emptyWidget.Render(renderLogo(lines, m.idleWidth()))
+}
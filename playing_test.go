@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// withPlayingOnly liga PLAYING_ONLY com o painel "sobre" about.
+func withPlayingOnly(t *testing.T, about []string) {
+	t.Helper()
+	prevOnly, prevAbout := playingOnly, aboutLines
+	playingOnly, aboutLines = true, about
+	t.Cleanup(func() { playingOnly, aboutLines = prevOnly, prevAbout })
+}
+
+func TestWidgetHidden(t *testing.T) {
+	tests := []struct {
+		name  string
+		only  bool
+		track *spotify.Track
+		want  bool
+	}{
+		{"off without track", false, nil, false},
+		{"off paused", false, &spotify.Track{Name: "Song"}, false},
+		{"without track", true, nil, true},
+		{"paused", true, &spotify.Track{Name: "Song"}, true},
+		{"history fallback", true, &spotify.Track{Name: "Song", PlayedAt: time.Now()}, true},
+		{"playing", true, &spotify.Track{Name: "Song", IsPlaying: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPlayingOnly(t, nil)
+			playingOnly = tt.only
+			m := newModel(80, 24, nil, false)
+			m.currentTrack = tt.track
+			if got := m.widgetHidden(); got != tt.want {
+				t.Errorf("widgetHidden = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayingOnlyView(t *testing.T) {
+	withPlayingOnly(t, []string{"Sobre mim"})
+
+	m := viewModel()
+	m.currentTrack.IsPlaying = false
+	view := m.View()
+	if !strings.Contains(view, "Sobre mim") || strings.Contains(view, m.currentTrack.Album) {
+		t.Error("paused view does not swap the widget for the about panel")
+	}
+
+	// A próxima busca com a música tocando traz o widget de volta
+	m, _ = m.applyTrack(trackMsg{track: &spotify.Track{Name: "Song", Album: "Album", IsPlaying: true}})
+	view = m.View()
+	if strings.Contains(view, "Sobre mim") || !strings.Contains(view, "Album") {
+		t.Error("playing view still shows the about panel")
+	}
+}
+
+func TestAboutDefaultText(t *testing.T) {
+	withPlayingOnly(t, nil)
+	prevLogo := idleLogo
+	idleLogo = nil
+	t.Cleanup(func() { idleLogo = prevLogo })
+
+	if view := newModel(80, 24, nil, false).View(); !strings.Contains(view, text.About) {
+		t.Errorf("view without ABOUT_FILE or logo = %q, want %q", view, text.About)
+	}
+}
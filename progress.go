@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// progressInterval é o intervalo de atualização da barra de progresso.
+const progressInterval = time.Second
+
+// playbackState distingue o que o widget está mostrando.
+type playbackState int
+
+const (
+	statePlaying playbackState = iota // Música atual, tocando
+	statePaused                       // Música atual, pausada
+	stateRecent                       // Nada tocando: última música do histórico
+)
+
+// playbackStateOf classifica track. Itens do histórico (fallback quando
+// nada está tocando) são os únicos com PlayedAt preenchido.
+func playbackStateOf(track *spotify.Track) playbackState {
+	switch {
+	case !track.PlayedAt.IsZero():
+		return stateRecent
+	case track.IsPlaying:
+		return statePlaying
+	default:
+		return statePaused
+	}
+}
+
+// elapsed estima a posição atual da reprodução: a posição informada na
+// busca mais o tempo desde então, se estiver tocando.
+func (m model) elapsed() time.Duration {
+	t := m.currentTrack
+	pos := time.Duration(t.ProgressMs) * time.Millisecond
+	if t.IsPlaying && !m.fetchedAt.IsZero() {
+		pos += time.Since(m.fetchedAt)
+	}
+	if t.DurationMs > 0 {
+		pos = min(pos, time.Duration(t.DurationMs)*time.Millisecond)
+	}
+	return pos
+}
+
+// progressStyle é o estilo da barra de progresso (tecla b).
+type progressStyle int
+
+const (
+	progressBlocks  progressStyle = iota // ━━━━──── (padrão)
+	progressBraille                      // ⣿⣿⣷⣀⣀ com 8 passos por coluna
+	progressSmooth                       // ███▋──── com 8 passos por coluna
+	progressText                         // Só o tempo, sem barra
+	numProgressStyles
+)
+
+// progressBars renderiza a barra de cada estilo a partir da fração tocada
+// (0 a 1) e da largura em colunas. nil para estilos sem barra.
+var progressBars = [numProgressStyles]func(frac float64, width int) string{
+	progressBlocks:  blockBar,
+	progressBraille: brailleBar,
+	progressSmooth:  smoothBar,
+}
+
+// progressLine renderiza "▶ ━━━━━──── 1:23/3:45" (ou ❚❚ quando pausado)
+// em width colunas, no estilo da sessão. Sem duração (arquivos locais,
+// alguns episódios) mostra só o tempo decorrido. Vazio para o histórico.
+func (m model) progressLine(width int) string {
+	t := m.currentTrack
+	state := playbackStateOf(t)
+	if state == stateRecent {
+		return ""
+	}
+
+	glyph := "▶"
+	if state == statePaused {
+		glyph = "❚❚"
+	}
+
+	elapsed := m.elapsed()
+	if t.DurationMs <= 0 {
+		return glyph + " " + formatDuration(elapsed)
+	}
+
+	duration := time.Duration(t.DurationMs) * time.Millisecond
+	times := formatDuration(elapsed) + "/" + formatDuration(duration)
+
+	bar := progressBars[m.progressStyle]
+	barWidth := width - len([]rune(glyph)) - len(times) - 2
+	if bar == nil || barWidth < 4 {
+		return glyph + " " + times
+	}
+	frac := float64(elapsed) / float64(duration)
+	return glyph + " " + bar(frac, barWidth) + " " + times
+}
+
+// blockBar desenha uma barra de width colunas com ━ na parte tocada.
+func blockBar(frac float64, width int) string {
+	filled := max(min(int(frac*float64(width)), width), 0)
+	return strings.Repeat("━", filled) + strings.Repeat("─", width-filled)
+}
+
+// brailleSteps preenche uma coluna braille ponto a ponto, da esquerda
+// para a direita e de baixo para cima.
+var brailleSteps = []rune("⡀⡄⡆⡇⣇⣧⣷⣿")
+
+// brailleBar desenha uma barra de width colunas com resolução de 8
+// passos por coluna, usando os pontos dos caracteres braille.
+func brailleBar(frac float64, width int) string {
+	steps := len(brailleSteps)
+	filled := max(min(int(frac*float64(width*steps)), width*steps), 0)
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("⣿", filled/steps))
+	if rest := filled % steps; rest > 0 {
+		sb.WriteRune(brailleSteps[rest-1])
+	}
+	if empty := width - (filled+steps-1)/steps; empty > 0 {
+		sb.WriteString(strings.Repeat("⣀", empty))
+	}
+	return sb.String()
+}
+
+// eighthBlocks são os blocos parciais de 1/8 a 7/8 de coluna, da esquerda
+// para a direita; a coluna cheia é █.
+var eighthBlocks = []rune("▏▎▍▌▋▊▉")
+
+// smoothBar desenha uma barra de width colunas com █ na parte tocada e a
+// ponta em oitavos de coluna, para a barra andar sem saltos. Em 0% é só
+// trilho; em 100%, só blocos cheios, sem ponta parcial.
+func smoothBar(frac float64, width int) string {
+	steps := len(eighthBlocks) + 1
+	filled := max(min(int(frac*float64(width*steps)), width*steps), 0)
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("█", filled/steps))
+	if rest := filled % steps; rest > 0 {
+		sb.WriteRune(eighthBlocks[rest-1])
+	}
+	if empty := width - (filled+steps-1)/steps; empty > 0 {
+		sb.WriteString(strings.Repeat("─", empty))
+	}
+	return sb.String()
+}
+
+// formatDuration formata d como "m:ss", ou "h:mm:ss" a partir de uma hora.
+func formatDuration(d time.Duration) string {
+	s := int(d / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
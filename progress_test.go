@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"ssh-portfolio/spotify"
+)
+
+func TestSmoothBar(t *testing.T) {
+	tests := []struct {
+		frac float64
+		want string
+	}{
+		{0, "────"},
+		{1.0 / 32, "▏───"},
+		{0.25, "█───"},
+		{0.25 + 3.0/32, "█▍──"},
+		{0.5 + 7.0/32, "██▉─"},
+		{1, "████"},
+		{-0.5, "────"},
+		{1.5, "████"},
+	}
+	for _, tt := range tests {
+		got := smoothBar(tt.frac, 4)
+		if got != tt.want {
+			t.Errorf("smoothBar(%v, 4) = %q, want %q", tt.frac, got, tt.want)
+		}
+		if n := utf8.RuneCountInString(got); n != 4 {
+			t.Errorf("smoothBar(%v, 4) is %d columns wide, want 4", tt.frac, n)
+		}
+	}
+}
+
+func TestBlockBar(t *testing.T) {
+	tests := []struct {
+		frac float64
+		want string
+	}{
+		{0, "────"},
+		{0.24, "────"},
+		{0.5, "━━──"},
+		{1, "━━━━"},
+		{-0.5, "────"},
+		{1.5, "━━━━"},
+	}
+	for _, tt := range tests {
+		if got := blockBar(tt.frac, 4); got != tt.want {
+			t.Errorf("blockBar(%v, 4) = %q, want %q", tt.frac, got, tt.want)
+		}
+	}
+}
+
+func TestBrailleBar(t *testing.T) {
+	tests := []struct {
+		frac float64
+		want string
+	}{
+		{0, "⣀⣀⣀⣀"},
+		{1.0 / 32, "⡀⣀⣀⣀"},
+		{0.25, "⣿⣀⣀⣀"},
+		{0.25 + 3.0/32, "⣿⡆⣀⣀"},
+		{0.5 + 7.0/32, "⣿⣿⣷⣀"},
+		{1, "⣿⣿⣿⣿"},
+		{-0.5, "⣀⣀⣀⣀"},
+		{1.5, "⣿⣿⣿⣿"},
+	}
+	for _, tt := range tests {
+		got := brailleBar(tt.frac, 4)
+		if got != tt.want {
+			t.Errorf("brailleBar(%v, 4) = %q, want %q", tt.frac, got, tt.want)
+		}
+		if n := utf8.RuneCountInString(got); n != 4 {
+			t.Errorf("brailleBar(%v, 4) is %d columns wide, want 4", tt.frac, n)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0:00"},
+		{9 * time.Second, "0:09"},
+		{3*time.Minute + 45*time.Second, "3:45"},
+		{59*time.Minute + 59*time.Second + 999*time.Millisecond, "59:59"},
+		{time.Hour, "1:00:00"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+		{12*time.Hour + 34*time.Minute + 56*time.Second, "12:34:56"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPlaybackStateOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		track *spotify.Track
+		want  playbackState
+	}{
+		{"playing", &spotify.Track{IsPlaying: true}, statePlaying},
+		{"paused", &spotify.Track{}, statePaused},
+		{"recently played", &spotify.Track{PlayedAt: time.Now()}, stateRecent},
+		// O histórico ganha mesmo com IsPlaying: o item não é a música atual
+		{"recent marked playing", &spotify.Track{IsPlaying: true, PlayedAt: time.Now()}, stateRecent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := playbackStateOf(tt.track); got != tt.want {
+				t.Errorf("playbackStateOf = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPausedBarHolds(t *testing.T) {
+	m := viewModel()
+	paused := &spotify.Track{Name: "Song", Artist: "Artist", DurationMs: 200000, ProgressMs: 50000}
+	next, _ := m.Update(trackMsg{track: paused, at: time.Now().Add(-time.Minute)})
+	m = next.(model)
+	if m.tickers.running(tickerProgress) {
+		t.Error("progress ticker running while paused")
+	}
+
+	before := m.progressLine(60)
+	if !strings.HasPrefix(before, "❚❚") || !strings.Contains(before, "0:50/3:20") {
+		t.Fatalf("paused line = %q, want ❚❚ at 0:50/3:20", before)
+	}
+	// Mais tempo desde a busca, como se vários ticks tivessem passado
+	m.fetchedAt = m.fetchedAt.Add(-30 * time.Second)
+	if after := m.progressLine(60); after != before {
+		t.Errorf("paused bar moved: %q, then %q", before, after)
+	}
+
+	// Tocando, o mesmo intervalo avança a barra
+	m.currentTrack = &spotify.Track{Name: "Song", IsPlaying: true, DurationMs: 200000, ProgressMs: 50000}
+	if playing := m.progressLine(60); playing == before || strings.Contains(playing, "0:50/") {
+		t.Errorf("playing line = %q, want it past 0:50", playing)
+	}
+}
+
+func TestProgressLineWithoutDuration(t *testing.T) {
+	m := viewModel()
+	for style := range numProgressStyles {
+		m.progressStyle = style
+		m.currentTrack = &spotify.Track{Name: "Faixa Local", IsPlaying: true, ProgressMs: 83000}
+		m.fetchedAt = time.Time{}
+		if got, want := m.progressLine(60), "▶ 1:23"; got != want {
+			t.Errorf("style %d, playing: progressLine = %q, want %q", style, got, want)
+		}
+
+		m.currentTrack.IsPlaying = false
+		if got, want := m.progressLine(60), "❚❚ 1:23"; got != want {
+			t.Errorf("style %d, paused: progressLine = %q, want %q", style, got, want)
+		}
+	}
+}
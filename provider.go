@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/log"
+)
+
+// trackUpdate é o resultado de uma busca no Spotify.
+type trackUpdate struct {
+	track   *spotify.Track
+	queue   *spotify.Queue
+	err     error
+	source  trackSource      // De onde veio track
+	latency time.Duration    // Tempo gasto buscando a música (sem contar a fila)
+	at      time.Time        // Quando a música foi buscada, para estimar o progresso
+	history []*spotify.Track // Músicas tocadas em activityWindow, da mais antiga à mais recente
+}
+
+// trackSource diz de onde veio a música de um trackUpdate.
+type trackSource int
+
+const (
+	sourceNone    trackSource = iota // Nenhuma música: nada tocando ou as buscas falharam
+	sourceCurrent                    // A música tocando agora
+	sourceRecent                     // Fallback: a última música tocada
+)
+
+func (s trackSource) String() string {
+	switch s {
+	case sourceCurrent:
+		return "atual"
+	case sourceRecent:
+		return "recente"
+	}
+	return "nenhuma"
+}
+
+// historyInterval é o intervalo mínimo entre buscas do histórico.
+// O histórico muda devagar e só alimenta o sparkline; não precisa
+// acompanhar o intervalo de polling da música atual.
+const historyInterval = 5 * time.Minute
+
+// Provider consulta o Spotify em segundo plano e publica cada resultado
+// para todos os inscritos (sessões SSH, stream SSE...).
+// Assim a conta do dono é consultada uma vez por intervalo, não uma vez por sessão.
+type Provider struct {
+	client *spotify.Client
+	cfg    ProviderConfig
+
+	mu      sync.RWMutex
+	last    trackUpdate
+	hasLast bool
+	subs    map[chan trackUpdate]struct{}
+
+	history   []*spotify.Track // Só acessado pela goroutine do loop
+	historyAt time.Time
+
+	failures int           // Buscas seguidas com erro; protegido por mu
+	delay    time.Duration // Espera até a próxima busca; protegido por mu
+
+	wake    chan struct{} // Sinaliza uma nova inscrição para o loop ocioso
+	refresh chan struct{} // Pedido de busca antecipada (ver Refresh)
+	retry   chan struct{} // Pedido para furar o backoff (ver Retry)
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// ProviderConfig configura o polling do Provider.
+type ProviderConfig struct {
+	// Interval é o intervalo entre buscas. Zero ou negativo usa pollInterval.
+	Interval time.Duration
+
+	// AlwaysOn mantém o polling mesmo sem inscritos (ex.: para o stream SSE
+	// responder na hora). Desligado, o provider para de consultar a API
+	// enquanto ninguém está assistindo, economizando a cota da conta.
+	AlwaysOn bool
+
+	// PausedInterval é o intervalo entre buscas enquanto a música está
+	// pausada (ou é só a última tocada): nada vai mudar até a reprodução
+	// voltar, então buscar a cada Interval só gasta a cota. A retomada é
+	// percebida na próxima busca espaçada ou num Refresh. Zero ou negativo
+	// usa defaultPausedInterval; igual a Interval desliga o espaçamento.
+	PausedInterval time.Duration
+
+	// FallbackOnError tenta o histórico também quando a busca da música
+	// atual falha com erro do servidor (5xx), e não só quando nada toca:
+	// melhor mostrar a última música tocada do que um erro passageiro.
+	FallbackOnError bool
+
+	// MaxBackoff limita a espera entre buscas durante falhas seguidas da
+	// API: a cada falha o intervalo dobra, até este teto, e volta ao normal
+	// no primeiro sucesso. Zero ou negativo usa defaultMaxBackoff.
+	MaxBackoff time.Duration
+}
+
+// defaultMaxBackoff é o teto padrão do backoff durante instabilidades da API.
+const defaultMaxBackoff = 5 * time.Minute
+
+// defaultPausedInterval é o intervalo padrão entre buscas com a música pausada.
+const defaultPausedInterval = time.Minute
+
+// StartProvider inicia o polling em uma goroutine e retorna o provider.
+// A primeira busca acontece imediatamente (ou no primeiro inscrito, se
+// cfg.AlwaysOn estiver desligado).
+func StartProvider(client *spotify.Client, cfg ProviderConfig) *Provider {
+	if cfg.Interval <= 0 {
+		cfg.Interval = pollInterval
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.PausedInterval <= 0 {
+		cfg.PausedInterval = defaultPausedInterval
+	}
+
+	p := &Provider{
+		client:  client,
+		cfg:     cfg,
+		subs:    make(map[chan trackUpdate]struct{}),
+		wake:    make(chan struct{}, 1),
+		refresh: make(chan struct{}, 1),
+		retry:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Subscribe registra um novo inscrito e retorna o canal de atualizações
+// e a função que cancela a inscrição (e fecha o canal).
+//
+// O canal tem buffer de 1: um inscrito lento perde atualizações
+// intermediárias, mas sempre recebe a mais recente. Se já houver um
+// resultado, ele é entregue imediatamente.
+func (p *Provider) Subscribe() (<-chan trackUpdate, func()) {
+	ch := make(chan trackUpdate, 1)
+
+	p.mu.Lock()
+	p.subs[ch] = struct{}{}
+	if p.hasLast {
+		ch <- p.last
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			p.mu.Lock()
+			delete(p.subs, ch)
+			close(ch)
+			p.mu.Unlock()
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Current retorna o último resultado publicado.
+func (p *Provider) Current() trackUpdate {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.last
+}
+
+// Backoff retorna o número de buscas seguidas com erro e a espera atual
+// até a próxima busca.
+func (p *Provider) Backoff() (failures int, delay time.Duration) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.failures, p.delay
+}
+
+// Refresh pede uma busca antecipada quando o polling está espaçado por
+// causa de uma pausa (ver ProviderConfig.PausedInterval). Não fura o
+// backoff de falhas e nunca busca mais rápido que o intervalo normal,
+// então pode ser chamado à vontade pelas sessões.
+func (p *Provider) Refresh() {
+	select {
+	case p.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Retry fura o backoff de falhas e busca de novo na hora, para quando a
+// causa das falhas foi resolvida por fora (ex.: o primeiro token chegou
+// depois de a rede subir). Sem falhas, não faz nada: a espera é a normal.
+func (p *Provider) Retry() {
+	select {
+	case p.retry <- struct{}{}:
+	default:
+	}
+}
+
+// Stop encerra o polling e espera a goroutine terminar.
+func (p *Provider) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *Provider) run() {
+	defer close(p.done)
+
+	t := time.NewTimer(p.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		if !p.cfg.AlwaysOn {
+			// Descarta sinais antigos antes de checar: quem se inscrever
+			// depois da checagem ainda acorda o loop pelo canal.
+			select {
+			case <-p.wake:
+			default:
+			}
+
+			if p.subscribers() == 0 {
+				log.Debug("Provider ocioso, aguardando inscritos")
+				select {
+				case <-p.stop:
+					return
+				case <-p.wake:
+				}
+			}
+		}
+
+		u := fetchTrack(p.client, p.cfg.FallbackOnError)
+		u.history = p.refreshHistory()
+		p.publish(u)
+		t.Reset(p.schedule(u))
+		fetchedAt := time.Now()
+
+	wait:
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-t.C:
+				break wait
+			case <-p.refresh:
+				if p.refreshable(fetchedAt) {
+					break wait
+				}
+			case <-p.retry:
+				if failures, _ := p.Backoff(); failures > 0 {
+					break wait
+				}
+			}
+		}
+	}
+}
+
+// schedule retorna a espera até a próxima busca depois do resultado u:
+// o backoff em caso de erro, PausedInterval com a música parada e o
+// intervalo normal nos demais casos.
+func (p *Provider) schedule(u trackUpdate) time.Duration {
+	delay := p.backoff(u.err)
+	if u.err != nil || u.track == nil || u.track.IsPlaying || p.cfg.PausedInterval <= delay {
+		return delay
+	}
+
+	p.mu.Lock()
+	p.delay = p.cfg.PausedInterval
+	p.mu.Unlock()
+	log.Debug("Música parada, espaçando as buscas", "delay", p.cfg.PausedInterval)
+	return p.cfg.PausedInterval
+}
+
+// refreshable informa se um Refresh pode antecipar a próxima busca: só
+// sem falhas recentes e com pelo menos Interval desde a última.
+func (p *Provider) refreshable(fetchedAt time.Time) bool {
+	failures, _ := p.Backoff()
+	return failures == 0 && time.Since(fetchedAt) >= p.cfg.Interval
+}
+
+// backoff atualiza a contagem de falhas com o resultado da última busca
+// e retorna a espera até a próxima: o intervalo normal após um sucesso,
+// dobrando a cada falha seguida até cfg.MaxBackoff.
+func (p *Provider) backoff(err error) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.failures = 0
+		p.delay = p.cfg.Interval
+		return p.delay
+	}
+
+	p.failures++
+	p.delay = backoffDelay(p.cfg.Interval, p.cfg.MaxBackoff, p.failures)
+	log.Debug("Busca falhou, aguardando mais", "failures", p.failures, "delay", p.delay)
+	return p.delay
+}
+
+// backoffDelay retorna interval·2^failures, limitado a maxDelay.
+func backoffDelay(interval, maxDelay time.Duration, failures int) time.Duration {
+	d := interval
+	for range failures {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+	return d
+}
+
+// refreshHistory busca o histórico se o último resultado for mais velho
+// que historyInterval. Em caso de erro mantém o histórico anterior.
+func (p *Provider) refreshHistory() []*spotify.Track {
+	// O histórico usa o mesmo scope do fallback
+	if time.Since(p.historyAt) < historyInterval || !p.client.RecentlyPlayedFallback() {
+		return p.history
+	}
+
+	history, err := p.client.GetRecentlyPlayedSince(time.Now().Add(-activityWindow))
+	if err != nil {
+		log.Debug("Falha ao buscar histórico", "error", err)
+		return p.history
+	}
+
+	p.history = history
+	p.historyAt = time.Now()
+	return p.history
+}
+
+// subscribers retorna o número de inscritos ativos.
+func (p *Provider) subscribers() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.subs)
+}
+
+// publish guarda u como último resultado e entrega a todos os inscritos,
+// substituindo qualquer atualização ainda não lida.
+func (p *Provider) publish(u trackUpdate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.last = u
+	p.hasLast = true
+
+	for ch := range p.subs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- u
+	}
+}
+
+// fetchTrack busca a música atual, caindo para a última tocada quando
+// nada está tocando ou, com fallbackOnError, quando a busca falha com erro
+// do servidor. O source do resultado diz qual das duas buscas deu a música.
+//
+// Se as duas falharem, o erro junta os dois; se só o fallback falhar,
+// o erro é o dele.
+func fetchTrack(client *spotify.Client, fallbackOnError bool) trackUpdate {
+	start := time.Now()
+	track, err := client.GetCurrentlyPlaying()
+	if err == nil && track != nil {
+		at := time.Now()
+		latency := at.Sub(start)
+
+		// A fila é um extra: se falhar, mostramos a música sem o indicador
+		queue, qerr := client.GetQueue()
+		if qerr != nil {
+			log.Debug("Falha ao buscar fila", "error", qerr)
+		}
+
+		return trackUpdate{track: track, queue: queue, source: sourceCurrent, latency: latency, at: at}
+	}
+
+	fallback := client.RecentlyPlayedFallback() &&
+		(err == nil || fallbackOnError && spotify.IsServerError(err))
+	if !fallback {
+		return trackUpdate{err: err, latency: time.Since(start)}
+	}
+
+	recent, rerr := client.GetRecentlyPlayed()
+	u := trackUpdate{latency: time.Since(start)}
+	switch {
+	case rerr != nil && err != nil:
+		u.err = fmt.Errorf("currently playing: %w; recently played: %w", err, rerr)
+	case rerr != nil:
+		u.err = rerr
+	case recent != nil:
+		if err != nil {
+			log.Debug("Falha ao buscar a música atual, usando a última tocada", "error", err)
+		}
+		recent.IsPlaying = false
+		u.track, u.source = recent, sourceRecent
+	}
+	return u
+}
+
+// sameTrack informa se a e b representam a mesma música no mesmo estado.
+func sameTrack(a, b *spotify.Track) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Name == b.Name &&
+		a.Artist == b.Artist &&
+		a.Album == b.Album &&
+		a.ArtworkURL == b.ArtworkURL &&
+		a.IsPlaying == b.IsPlaying
+}
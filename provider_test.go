@@ -0,0 +1,474 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// fakeAPI simula os endpoints do Spotify usados pelo provider. Cada campo
+// é o status devolvido pelo endpoint; zero responde com sucesso (e
+// current com 204 responde "nada tocando").
+type fakeAPI struct {
+	token, current, recent atomic.Int32
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond := func(status *atomic.Int32, body any) {
+		if s := int(status.Load()); s != 0 {
+			w.WriteHeader(s)
+			return
+		}
+		json.NewEncoder(w).Encode(body)
+	}
+	item := func(name string) map[string]any {
+		return map[string]any{"name": name, "artists": []map[string]any{{"name": "Artist"}}}
+	}
+
+	switch r.URL.Path {
+	case "/api/token":
+		respond(&f.token, map[string]any{"access_token": "token", "expires_in": 3600})
+	case "/v1/me/player/currently-playing":
+		respond(&f.current, map[string]any{"is_playing": true, "item": item("Current")})
+	case "/v1/me/player/recently-played":
+		respond(&f.recent, map[string]any{"items": []map[string]any{
+			{"track": item("Recent"), "played_at": time.Now().Format(time.RFC3339)},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// rewriteTransport manda todas as requests para target, mantendo caminho
+// e query: as URLs da API são fixas no cliente.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// fakeSpotify cria um cliente do Spotify que fala com handler.
+func fakeSpotify(t *testing.T, handler http.Handler, opts ...spotify.Option) *spotify.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	opts = append([]spotify.Option{spotify.WithTransport(rewriteTransport{target})}, opts...)
+	return spotify.NewClient("id", "secret", "refresh", opts...)
+}
+
+// eventually espera cond ficar verdadeira, falhando o teste após 2s.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStartupTokenRetryWakesProvider(t *testing.T) {
+	prevDelay := tokenRetryDelay
+	tokenRetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { tokenRetryDelay = prevDelay })
+
+	api := &fakeAPI{}
+	api.token.Store(http.StatusServiceUnavailable)
+	client := fakeSpotify(t, api)
+
+	// Intervalo longo: sem Retry, o backoff seguraria a próxima busca por horas
+	p := StartProvider(client, ProviderConfig{Interval: time.Hour, AlwaysOn: true})
+	t.Cleanup(p.Stop)
+	eventually(t, "the first fetch to fail", func() bool {
+		failures, _ := p.Backoff()
+		return failures > 0
+	})
+
+	// A rede volta no meio das tentativas de waitForToken
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		api.token.Store(0)
+	}()
+	if !waitForToken(client, 5*time.Second) {
+		t.Fatal("waitForToken gave up")
+	}
+	p.Retry()
+
+	eventually(t, "a track after the token arrived", func() bool {
+		return p.Current().track != nil
+	})
+	if failures, delay := p.Backoff(); failures != 0 || delay != time.Hour {
+		t.Errorf("Backoff() = %d, %v; want 0, 1h", failures, delay)
+	}
+}
+
+func TestRetryWithoutFailuresKeepsInterval(t *testing.T) {
+	var fetches atomic.Int32
+	api := &fakeAPI{}
+	client := fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/me/player/currently-playing" {
+			fetches.Add(1)
+		}
+		api.ServeHTTP(w, r)
+	}))
+
+	p := StartProvider(client, ProviderConfig{Interval: time.Hour, AlwaysOn: true})
+	t.Cleanup(p.Stop)
+	eventually(t, "the first fetch", func() bool { return p.Current().track != nil })
+
+	p.Retry()
+	time.Sleep(50 * time.Millisecond)
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("%d fetches, want 1: Retry must not cut a healthy interval", n)
+	}
+}
+
+// countingAPI é um fakeAPI que conta as buscas da música atual e do
+// histórico.
+type countingAPI struct {
+	fakeAPI
+	fetches, recents atomic.Int32
+}
+
+func (c *countingAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/me/player/currently-playing":
+		c.fetches.Add(1)
+	case "/v1/me/player/recently-played":
+		c.recents.Add(1)
+	}
+	c.fakeAPI.ServeHTTP(w, r)
+}
+
+func TestProviderIdleWithoutSubscribers(t *testing.T) {
+	api := &countingAPI{}
+	p := StartProvider(fakeSpotify(t, api), ProviderConfig{Interval: 10 * time.Millisecond})
+	t.Cleanup(p.Stop)
+
+	time.Sleep(50 * time.Millisecond)
+	if n := api.fetches.Load(); n != 0 {
+		t.Fatalf("%d fetches without subscribers, want 0", n)
+	}
+
+	updates, unsubscribe := p.Subscribe()
+	select {
+	case u := <-updates:
+		if u.track == nil || u.track.Name != "Current" {
+			t.Errorf("first update = %+v, want the current track", u.track)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update after subscribing")
+	}
+	eventually(t, "polling at the interval", func() bool { return api.fetches.Load() >= 3 })
+
+	// Sem inscritos, o polling para depois da busca em andamento
+	unsubscribe()
+	time.Sleep(30 * time.Millisecond)
+	idle := api.fetches.Load()
+	time.Sleep(50 * time.Millisecond)
+	if n := api.fetches.Load(); n != idle {
+		t.Errorf("%d fetches after the last unsubscribe, want none", n-idle)
+	}
+}
+
+func TestProviderAlwaysOn(t *testing.T) {
+	api := &countingAPI{}
+	p := StartProvider(fakeSpotify(t, api), ProviderConfig{Interval: 10 * time.Millisecond, AlwaysOn: true})
+	t.Cleanup(p.Stop)
+
+	eventually(t, "polling without subscribers", func() bool { return api.fetches.Load() >= 3 })
+	if u := p.Current(); u.track == nil || u.track.Name != "Current" {
+		t.Errorf("Current() = %+v, want the current track", u.track)
+	}
+}
+
+func TestProviderDefaults(t *testing.T) {
+	p := StartProvider(fakeSpotify(t, &fakeAPI{}), ProviderConfig{})
+	t.Cleanup(p.Stop)
+
+	if p.cfg.Interval != pollInterval || p.cfg.MaxBackoff != defaultMaxBackoff || p.cfg.PausedInterval != defaultPausedInterval {
+		t.Errorf("cfg = %+v, want the defaults", p.cfg)
+	}
+}
+
+func TestProviderStop(t *testing.T) {
+	api := &countingAPI{}
+	p := StartProvider(fakeSpotify(t, api), ProviderConfig{Interval: 10 * time.Millisecond, AlwaysOn: true})
+	eventually(t, "the first fetch", func() bool { return api.fetches.Load() > 0 })
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	n := api.fetches.Load()
+	time.Sleep(50 * time.Millisecond)
+	if after := api.fetches.Load(); after != n {
+		t.Errorf("%d fetches after Stop, want none", after-n)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	const interval, maxDelay = 10 * time.Second, 5 * time.Minute
+	want := []time.Duration{
+		10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second,
+		160 * time.Second, 5 * time.Minute, 5 * time.Minute,
+	}
+	for failures, w := range want {
+		if got := backoffDelay(interval, maxDelay, failures); got != w {
+			t.Errorf("backoffDelay(%d failures) = %v, want %v", failures, got, w)
+		}
+	}
+	// Muitas falhas não estouram a duração
+	if got := backoffDelay(interval, maxDelay, 1000); got != maxDelay {
+		t.Errorf("backoffDelay(1000 failures) = %v, want %v", got, maxDelay)
+	}
+}
+
+func TestProviderBackoffGrowsAndResets(t *testing.T) {
+	p := &Provider{cfg: ProviderConfig{Interval: time.Second, MaxBackoff: 8 * time.Second}}
+	fail := errors.New("503")
+
+	for i, want := range []time.Duration{2, 4, 8, 8} {
+		if got := p.backoff(fail); got != want*time.Second {
+			t.Errorf("failure %d: delay %v, want %v", i+1, got, want*time.Second)
+		}
+	}
+	if failures, _ := p.Backoff(); failures != 4 {
+		t.Errorf("failures = %d, want 4", failures)
+	}
+
+	if got := p.backoff(nil); got != time.Second {
+		t.Errorf("delay after success = %v, want the interval", got)
+	}
+	if failures, delay := p.Backoff(); failures != 0 || delay != time.Second {
+		t.Errorf("Backoff() after success = %d, %v; want 0, 1s", failures, delay)
+	}
+}
+
+func TestProviderBackoffOnOutage(t *testing.T) {
+	api := &fakeAPI{}
+	api.current.Store(http.StatusServiceUnavailable)
+	api.recent.Store(http.StatusServiceUnavailable)
+	p := StartProvider(fakeSpotify(t, api), ProviderConfig{
+		Interval:   5 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+		AlwaysOn:   true,
+	})
+	t.Cleanup(p.Stop)
+
+	eventually(t, "the backoff to reach its cap", func() bool {
+		failures, delay := p.Backoff()
+		return failures >= 3 && delay == 20*time.Millisecond
+	})
+
+	api.current.Store(0)
+	eventually(t, "the backoff to reset", func() bool {
+		failures, delay := p.Backoff()
+		return failures == 0 && delay == 5*time.Millisecond
+	})
+}
+
+func TestRecentlyPlayedFallbackDisabled(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint("enabled=", enabled), func(t *testing.T) {
+			api := &countingAPI{}
+			api.current.Store(http.StatusNoContent)
+			client := fakeSpotify(t, api, spotify.WithRecentlyPlayedFallback(enabled))
+
+			u := fetchTrack(client, true)
+			if u.err != nil {
+				t.Fatalf("fetchTrack error = %v", u.err)
+			}
+			p := &Provider{client: client}
+			history := p.refreshHistory()
+
+			if enabled {
+				if u.track == nil || u.source != sourceRecent || len(history) == 0 {
+					t.Errorf("track %v, source %v, %d in history; want the recent track and history",
+						u.track, u.source, len(history))
+				}
+				return
+			}
+			if n := api.recents.Load(); n != 0 {
+				t.Errorf("%d recently played requests with the fallback disabled, want 0", n)
+			}
+			if u.track != nil || history != nil {
+				t.Errorf("track %v, history %v; want nothing", u.track, history)
+			}
+		})
+	}
+}
+
+func TestCheckScopesSkipsDisabledFallback(t *testing.T) {
+	api := &countingAPI{}
+	client := fakeSpotify(t, api, spotify.WithRecentlyPlayedFallback(false))
+	if _, err := client.CheckScopes(); err != nil {
+		t.Fatal(err)
+	}
+	if n := api.recents.Load(); n != 0 {
+		t.Errorf("CheckScopes probed recently played %d times with the fallback disabled", n)
+	}
+}
+
+func TestFetchTrackFallback(t *testing.T) {
+	const (
+		ok          = 0
+		nothing     = http.StatusNoContent
+		serverError = http.StatusServiceUnavailable
+		forbidden   = http.StatusForbidden
+	)
+
+	tests := []struct {
+		name            string
+		current, recent int32
+		fallbackOnError bool
+		wantTrack       string
+		wantSource      trackSource
+		wantErrors      []int // Status dos erros esperados em u.err
+		wantRecentCall  bool
+	}{
+		{"current ok", ok, ok, true, "Current", sourceCurrent, nil, false},
+		{"nothing playing, recent ok", nothing, ok, true, "Recent", sourceRecent, nil, true},
+		{"nothing playing, recent fails", nothing, serverError, true, "", 0, []int{serverError}, true},
+		{"current fails, recent ok", serverError, ok, true, "Recent", sourceRecent, nil, true},
+		{"both fail", serverError, serverError, true, "", 0, []int{serverError, serverError}, true},
+		{"current fails, fallback on error off", serverError, ok, false, "", 0, []int{serverError}, false},
+		{"client error never falls back", forbidden, ok, true, "", 0, []int{forbidden}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &countingAPI{}
+			api.current.Store(tt.current)
+			api.recent.Store(tt.recent)
+
+			u := fetchTrack(fakeSpotify(t, api), tt.fallbackOnError)
+
+			var name string
+			if u.track != nil {
+				name = u.track.Name
+				if u.source == sourceRecent && u.track.IsPlaying {
+					t.Error("recent track marked as playing")
+				}
+			}
+			if name != tt.wantTrack || (u.track != nil && u.source != tt.wantSource) {
+				t.Errorf("track %q from %v, want %q from %v", name, u.source, tt.wantTrack, tt.wantSource)
+			}
+			if called := api.recents.Load() > 0; called != tt.wantRecentCall {
+				t.Errorf("recently played called = %v, want %v", called, tt.wantRecentCall)
+			}
+
+			// Com as duas falhas, o erro carrega as duas causas
+			if tt.wantErrors == nil {
+				if u.err != nil {
+					t.Errorf("err = %v, want nil", u.err)
+				}
+				return
+			}
+			var statusErr *spotify.StatusError
+			if !errors.As(u.err, &statusErr) || statusErr.Status != tt.wantErrors[0] {
+				t.Fatalf("err = %v, want status %d", u.err, tt.wantErrors[0])
+			}
+			if joined, ok := u.err.(interface{ Unwrap() []error }); ok != (len(tt.wantErrors) > 1) {
+				t.Errorf("err = %v, want %d causes", u.err, len(tt.wantErrors))
+			} else if ok && len(joined.Unwrap()) != len(tt.wantErrors) {
+				t.Errorf("err has %d causes, want %d", len(joined.Unwrap()), len(tt.wantErrors))
+			}
+		})
+	}
+}
+
+func TestProviderSchedule(t *testing.T) {
+	cfg := ProviderConfig{Interval: 10 * time.Second, PausedInterval: time.Minute, MaxBackoff: 5 * time.Minute}
+	playing := &spotify.Track{Name: "Song", IsPlaying: true}
+	paused := &spotify.Track{Name: "Song"}
+
+	tests := []struct {
+		name string
+		cfg  ProviderConfig
+		u    trackUpdate
+		want time.Duration
+	}{
+		{"playing", cfg, trackUpdate{track: playing}, 10 * time.Second},
+		{"paused", cfg, trackUpdate{track: paused}, time.Minute},
+		{"nothing at all", cfg, trackUpdate{}, 10 * time.Second},
+		{"error", cfg, trackUpdate{track: paused, err: errors.New("503")}, 20 * time.Second},
+		{"paused interval off", ProviderConfig{Interval: 10 * time.Second, PausedInterval: 10 * time.Second}, trackUpdate{track: paused}, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{cfg: tt.cfg}
+			if got := p.schedule(tt.u); got != tt.want {
+				t.Errorf("schedule = %v, want %v", got, tt.want)
+			}
+			if _, delay := p.Backoff(); delay != tt.want {
+				t.Errorf("Backoff delay = %v, want %v", delay, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderRefreshable(t *testing.T) {
+	p := &Provider{cfg: ProviderConfig{Interval: 10 * time.Second, MaxBackoff: time.Minute}}
+
+	if p.refreshable(time.Now().Add(-5 * time.Second)) {
+		t.Error("refresh allowed before the normal interval")
+	}
+	if !p.refreshable(time.Now().Add(-10 * time.Second)) {
+		t.Error("refresh denied after the normal interval")
+	}
+	p.backoff(errors.New("503"))
+	if p.refreshable(time.Now().Add(-time.Hour)) {
+		t.Error("refresh allowed during the failure backoff")
+	}
+}
+
+func TestProviderPausedPolling(t *testing.T) {
+	var fetches atomic.Int32
+	client := fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/token":
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		case "/v1/me/player/currently-playing":
+			fetches.Add(1)
+			w.Write([]byte(`{"is_playing":false,"item":{"name":"Paused"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	p := StartProvider(client, ProviderConfig{Interval: 5 * time.Millisecond, PausedInterval: time.Hour, AlwaysOn: true})
+	t.Cleanup(p.Stop)
+	eventually(t, "the first fetch", func() bool { return p.Current().track != nil })
+
+	time.Sleep(50 * time.Millisecond)
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("%d fetches while paused, want 1", n)
+	}
+
+	// A tecla u antecipa a busca: já passou mais que o intervalo normal
+	p.Refresh()
+	eventually(t, "the refreshed fetch", func() bool { return fetches.Load() == 2 })
+}
@@ -0,0 +1,296 @@
+package qr
+
+// builder monta a grade de módulos de uma versão.
+type builder struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool // Módulos dos padrões fixos, que a máscara não toca
+}
+
+func newBuilder(version int) *builder {
+	size := version*4 + 17
+	b := &builder{version: version, size: size}
+	b.modules = make([][]bool, size)
+	b.function = make([][]bool, size)
+	for y := range size {
+		b.modules[y] = make([]bool, size)
+		b.function[y] = make([]bool, size)
+	}
+	return b
+}
+
+// setFunction marca (x, y) como parte de um padrão fixo, com a cor dada.
+func (b *builder) setFunction(x, y int, black bool) {
+	b.modules[y][x] = black
+	b.function[y][x] = true
+}
+
+// drawFunctionPatterns desenha os padrões fixos: timing, os três
+// localizadores, os de alinhamento e a informação de versão. O formato
+// é reservado aqui e preenchido de verdade para cada máscara.
+func (b *builder) drawFunctionPatterns() {
+	for i := range b.size {
+		b.setFunction(6, i, i%2 == 0)
+		b.setFunction(i, 6, i%2 == 0)
+	}
+
+	b.drawFinder(3, 3)
+	b.drawFinder(b.size-4, 3)
+	b.drawFinder(3, b.size-4)
+
+	pos := b.alignmentPositions()
+	last := len(pos) - 1
+	for i, x := range pos {
+		for j, y := range pos {
+			// Os cantos com localizador não levam alinhamento
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			b.drawAlignment(x, y)
+		}
+	}
+
+	b.drawFormat(0)
+	b.drawVersion()
+}
+
+// drawFinder desenha um localizador centrado em (x, y), com a borda clara
+// de separação em volta.
+func (b *builder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= b.size || yy >= b.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			b.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment desenha um padrão de alinhamento 5×5 centrado em (x, y).
+func (b *builder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			b.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions retorna as coordenadas (iguais em x e y) dos
+// centros dos padrões de alinhamento da versão.
+func (b *builder) alignmentPositions() []int {
+	if b.version == 1 {
+		return nil
+	}
+	n := b.version/7 + 2
+	step := (b.version*4 + n*2 + 1) / (n*2 - 2) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, b.size-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// drawFormat desenha as duas cópias da informação de formato (nível de
+// correção e máscara) e o módulo escuro fixo.
+func (b *builder) drawFormat(mask int) {
+	data := formatLevelM<<3 | mask
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	// Em volta do localizador superior esquerdo
+	for i := range 6 {
+		b.setFunction(8, i, bit(i))
+	}
+	b.setFunction(8, 7, bit(6))
+	b.setFunction(8, 8, bit(7))
+	b.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.setFunction(14-i, 8, bit(i))
+	}
+
+	// Dividida entre os outros dois localizadores
+	for i := range 8 {
+		b.setFunction(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.setFunction(8, b.size-15+i, bit(i))
+	}
+	b.setFunction(8, b.size-8, true)
+}
+
+// drawVersion desenha os dois blocos 6×3 com a versão, a partir da 7.
+func (b *builder) drawVersion() {
+	if b.version < 7 {
+		return
+	}
+	rem := b.version
+	for range 12 {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := b.version<<12 | rem
+
+	for i := range 18 {
+		black := bits>>i&1 == 1
+		x, y := b.size-11+i%3, i/3
+		b.setFunction(x, y, black)
+		b.setFunction(y, x, black)
+	}
+}
+
+// drawCodewords distribui os bits de data pela grade no zigue-zague da
+// especificação: colunas duplas da direita para a esquerda, alternando
+// subida e descida, pulando os padrões fixos e a coluna de timing.
+func (b *builder) drawCodewords(data []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range b.size {
+			y := vert
+			if upward {
+				y = b.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if b.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				b.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverte os módulos de dados onde a máscara vale. Aplicar a
+// mesma máscara duas vezes restaura a grade.
+func (b *builder) applyMask(mask int) {
+	for y := range b.size {
+		for x := range b.size {
+			if b.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				b.modules[y][x] = !b.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty pontua a grade pelas quatro regras da especificação: sequências
+// longas da mesma cor, blocos 2×2, padrões parecidos com localizadores e
+// desequilíbrio entre claros e escuros. Menor é melhor.
+//
+// Onde a especificação deixa margem (as bordas na regra 3, o
+// arredondamento da regra 4), segue o MaskUtil do ZXing, a referência dos
+// testes: com outra pontuação a máscara escolhida muda e a grade também,
+// mesmo continuando legível.
+func (b *builder) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return b.modules[x][y]
+		}
+		return b.modules[y][x]
+	}
+	// light informa se os módulos [x0, x1) da linha são claros; fora da
+	// grade é a zona de silêncio, sempre clara
+	light := func(x0, x1, y int, transpose bool) bool {
+		for x := max(x0, 0); x < min(x1, b.size); x++ {
+			if at(x, y, transpose) {
+				return false
+			}
+		}
+		return true
+	}
+	finder := [7]bool{true, false, true, true, true, false, true}
+
+	total := 0
+	for _, transpose := range []bool{false, true} {
+		for y := range b.size {
+			run := 0
+			for x := range b.size {
+				if x > 0 && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					if run == 5 {
+						total += 3
+					} else if run > 5 {
+						total++
+					}
+				} else {
+					run = 1
+				}
+			}
+
+			// Regra 3: 1011101 com quatro claros de um dos lados, contado uma vez
+			for x := 0; x+len(finder) <= b.size; x++ {
+				match := true
+				for i, black := range finder {
+					if at(x+i, y, transpose) != black {
+						match = false
+						break
+					}
+				}
+				if match && (light(x-4, x, y, transpose) || light(x+7, x+11, y, transpose)) {
+					total += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := range b.size {
+		for x := range b.size {
+			c := b.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < b.size && y+1 < b.size &&
+				c == b.modules[y][x+1] && c == b.modules[y+1][x] && c == b.modules[y+1][x+1] {
+				total += 3
+			}
+		}
+	}
+
+	// Regra 4: 10 pontos a cada 5% completos de distância dos 50% escuros
+	cells := b.size * b.size
+	total += abs(dark*2-cells) * 10 / cells * 10
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
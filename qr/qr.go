@@ -0,0 +1,171 @@
+// Package qr gera QR codes (modelo 2) para textos curtos, como links.
+//
+// Só o necessário para o widget: modo byte, correção de erros nível M e
+// versões 1 a 9 (até 180 bytes), o bastante para qualquer link do Spotify.
+// A implementação segue a especificação ISO/IEC 18004 e a referência de
+// Project Nayuki.
+package qr
+
+import (
+	"errors"
+)
+
+// ErrTooLong indica que o texto não cabe na maior versão suportada.
+var ErrTooLong = errors.New("qr: text too long")
+
+const (
+	minVersion = 1
+	maxVersion = 9 // Até a 9, o tamanho no modo byte usa 8 bits
+
+	// formatLevelM são os bits do nível de correção M no formato.
+	formatLevelM = 0
+)
+
+// eccPerBlock e numBlocks são as tabelas do nível M, indexadas pela versão.
+var (
+	eccPerBlock = [maxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22}
+	numBlocks   = [maxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5}
+)
+
+// Code é um QR code pronto: uma grade Size×Size de módulos.
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Black informa se o módulo (x, y) é escuro. Fora da grade (a zona
+// de silêncio) é sempre claro.
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Encode gera o QR code de text na menor versão em que ele cabe.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if 4+8+len(data)*8 <= dataCodewords(version)*8 {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTooLong
+	}
+
+	codewords := addECC(encodeData(data, version), version)
+
+	b := newBuilder(version)
+	b.drawFunctionPatterns()
+	b.drawCodewords(codewords)
+
+	// Escolhe a máscara com menor penalidade, como manda a especificação
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		b.applyMask(mask)
+		b.drawFormat(mask)
+		if p := b.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		b.applyMask(mask) // XOR de novo desfaz a máscara
+	}
+	b.applyMask(best)
+	b.drawFormat(best)
+
+	return &Code{Size: b.size, modules: b.modules}, nil
+}
+
+// encodeData monta os codewords de dados: modo byte, tamanho, os bytes,
+// terminador e preenchimento até a capacidade da versão.
+func encodeData(data []byte, version int) []byte {
+	capacity := dataCodewords(version) * 8
+
+	var bits bitBuffer
+	bits.append(0b0100, 4) // Modo byte
+	bits.append(len(data), 8)
+	for _, c := range data {
+		bits.append(int(c), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// addECC divide data em blocos, calcula o Reed-Solomon de cada um e
+// intercala tudo na ordem em que os codewords vão para a grade.
+func addECC(data []byte, version int) []byte {
+	blocks := numBlocks[version]
+	eccLen := eccPerBlock[version]
+	raw := rawDataModules(version) / 8
+	numShort := blocks - raw%blocks
+	shortLen := raw / blocks
+
+	divisor := rsDivisor(eccLen)
+	var all [][]byte
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // Alinha com os blocos longos; pulado abaixo
+		}
+		all = append(all, append(block, ecc...))
+	}
+
+	var out []byte
+	for i := range all[0] {
+		for j, block := range all {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// rawDataModules é o número de módulos livres para dados e correção na
+// versão, descontados os padrões fixos.
+func rawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// dataCodewords é quantos codewords de dados cabem na versão no nível M.
+func dataCodewords(version int) int {
+	return rawDataModules(version)/8 - eccPerBlock[version]*numBlocks[version]
+}
+
+// bitBuffer é uma sequência de bits, do mais significativo ao menos.
+type bitBuffer []bool
+
+// append acrescenta os n bits menos significativos de v.
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>i&1 == 1)
+	}
+}
@@ -0,0 +1,145 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	zxqr "github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+)
+
+// Entradas nas fronteiras das versões, no modo byte e nível M: o maior
+// texto de cada versão e o primeiro que já não cabe nela. A partir da 7 a
+// grade leva os blocos de informação de versão.
+var boundaryTests = []struct {
+	name    string
+	text    string
+	version int
+}{
+	{"v1 max", pad("https://a.b/", 14), 1},
+	{"v2 min", pad("https://a.b/", 15), 2},
+	{"v6 max", pad("https://open.spotify.com/track/", 106), 6},
+	{"v7 min", pad("https://open.spotify.com/track/", 107), 7},
+	{"v7 max", pad("https://open.spotify.com/track/", 122), 7},
+	{"v8 min", pad("https://open.spotify.com/track/", 123), 8},
+	{"v9 max", pad("https://open.spotify.com/track/", 180), 9},
+	{"spotify link", "https://open.spotify.com/track/4cOdK2wGLETKBW3PvgPWqT", 4},
+}
+
+// pad completa prefix com letras minúsculas (que forçam o modo byte) até n bytes.
+func pad(prefix string, n int) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	for i := 0; sb.Len() < n; i++ {
+		sb.WriteByte(byte('a' + i%26))
+	}
+	return sb.String()
+}
+
+// TestEncodeMatchesReference compara a grade inteira com a do codificador
+// do ZXing (via gozxing) para o mesmo texto e nível: versão, padrões
+// fixos, formato, versão, dados, correção e a máscara escolhida.
+func TestEncodeMatchesReference(t *testing.T) {
+	for _, tt := range boundaryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.version*4 + 17; code.Size != want {
+				t.Fatalf("Size = %d (version %d), want %d (version %d)",
+					code.Size, (code.Size-17)/4, want, tt.version)
+			}
+
+			ref, err := encoder.Encoder_encodeWithoutHint(tt.text, decoder.ErrorCorrectionLevel_M)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matrix := ref.GetMatrix()
+			if matrix.GetWidth() != code.Size {
+				t.Fatalf("reference size %d, got %d", matrix.GetWidth(), code.Size)
+			}
+
+			var diffs int
+			for y := range code.Size {
+				for x := range code.Size {
+					if code.Black(x, y) != (matrix.Get(x, y) == 1) {
+						diffs++
+					}
+				}
+			}
+			if diffs > 0 {
+				t.Errorf("%d modules differ from the reference (mask %d)\ngot:\n%s\nwant:\n%s",
+					diffs, ref.GetMaskPattern(), dump(code), matrix.String())
+			}
+		})
+	}
+}
+
+// TestEncodeScans renderiza cada código como imagem e o lê com o
+// decodificador do ZXing.
+func TestEncodeScans(t *testing.T) {
+	for _, tt := range boundaryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bmp, err := gozxing.NewBinaryBitmapFromImage(toImage(code, 4))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := zxqr.NewQRCodeReader().Decode(bmp, nil)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got := result.GetText(); got != tt.text {
+				t.Errorf("decoded %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(pad("", 181)); err != ErrTooLong {
+		t.Errorf("181 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+// toImage desenha code com scale pixels por módulo e a zona de silêncio
+// de 4 módulos exigida pela especificação.
+func toImage(code *Code, scale int) image.Image {
+	const quiet = 4
+	size := (code.Size + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for py := range size {
+		for px := range size {
+			c := color.Gray{255}
+			if code.Black(px/scale-quiet, py/scale-quiet) {
+				c = color.Gray{0}
+			}
+			img.SetGray(px, py, c)
+		}
+	}
+	return img
+}
+
+// dump formata code como ByteMatrix.String do gozxing, para comparar.
+func dump(code *Code) string {
+	var sb strings.Builder
+	for y := range code.Size {
+		for x := range code.Size {
+			if code.Black(x, y) {
+				sb.WriteString(" 1")
+			} else {
+				sb.WriteString(" 0")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+
+	"ssh-portfolio/albumart"
+	"ssh-portfolio/qr"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// qrQuietZone é a margem clara em volta do QR, em módulos. A especificação
+// pede 4; sem ela, leitores se confundem com o fundo escuro do terminal.
+const qrQuietZone = 4
+
+// renderQR gera o QR code de url em half-blocks: cada módulo ocupa uma
+// coluna e meia linha, o que deixa os módulos quase quadrados.
+func renderQR(url string) (string, error) {
+	code, err := qr.Encode(url)
+	if err != nil {
+		return "", err
+	}
+
+	n := code.Size + 2*qrQuietZone
+	rows := (n + 1) / 2
+	img := image.NewGray(image.Rect(0, 0, n, rows*2))
+	for y := range rows * 2 {
+		for x := range n {
+			c := color.White
+			if code.Black(x-qrQuietZone, y-qrQuietZone) {
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	// Mesmo tamanho da imagem e vizinho mais próximo: nenhum módulo é borrado
+	return albumart.RenderImage(img, n, rows, albumart.Options{Interpolation: albumart.NearestNeighbor}), nil
+}
+
+// refreshQR gera o QR da música atual para a visão de QR, se o link mudou.
+func (m model) refreshQR() model {
+	url := ""
+	if m.currentTrack != nil {
+		url = m.currentTrack.URL
+	}
+	if url == m.qrFor {
+		return m
+	}
+
+	m.qrFor, m.qrCode = url, ""
+	if url != "" {
+		code, err := renderQR(url)
+		if err != nil {
+			log.Debug("Falha ao gerar QR code", "url", url, "error", err)
+		}
+		m.qrCode = code
+	}
+	return m
+}
+
+// renderQRWidget renderiza a visão de QR: o código do link da música
+// atual, para abrir no celular.
+func (m model) renderQRWidget() string {
+	if m.qrCode == "" {
+		return styles().emptyWidget.Render(styles().artist.Render("sem link para esta música"))
+	}
+	qrWidth := lipgloss.Width(m.qrCode)
+	if qrWidth+styles().widget.GetHorizontalFrameSize() > m.width {
+		return styles().emptyWidget.Render(styles().artist.Render("terminal estreito demais para o QR"))
+	}
+
+	return m.widgetStyle().Render(lipgloss.JoinVertical(lipgloss.Center,
+		styles().title.Render("♫ Aponte a câmera"),
+		"",
+		m.qrCode,
+		"",
+		styles().artist.Render(truncate(cmp.Or(m.currentTrack.Name, "Música desconhecida"), qrWidth)),
+	))
+}
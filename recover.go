@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// crashMessage é o que a sessão vê depois de um panic, antes de fechar.
+const crashMessage = "Algo deu errado. Encerrando a sessão.\n"
+
+// safeModel envolve o model de uma sessão e recupera panics em Init,
+// Update e View. O bubbletea já recupera panics, mas derruba o programa
+// imprimindo o stack na saída do servidor, sem dizer de qual sessão veio.
+// Aqui o panic é logado com o usuário e o endereço da sessão, a tela
+// mostra crashMessage e o programa termina normalmente, fechando a sessão.
+type safeModel struct {
+	inner tea.Model
+	sess  ssh.Session
+	crash *crashState // Compartilhado entre as cópias do model
+}
+
+// crashState registra se houve panic. View não devolve o model, então o
+// estado fica num ponteiro, e o programa é guardado para View poder
+// pedir o encerramento.
+type crashState struct {
+	failed  atomic.Bool
+	program *tea.Program
+}
+
+// safeProgramHandler cria o programa da sessão com o model de teaHandler
+// envolvido em safeModel. Substitui o handler padrão do bubbletea.Middleware
+// para ter acesso ao *tea.Program.
+func safeProgramHandler(s ssh.Session) *tea.Program {
+	inner, opts := teaHandler(s)
+	return newSafeProgram(s, inner, opts...)
+}
+
+// newSafeProgram cria o programa da sessão s com inner envolvido em safeModel.
+func newSafeProgram(s ssh.Session, inner tea.Model, opts ...tea.ProgramOption) *tea.Program {
+	m := safeModel{inner: inner, sess: s, crash: &crashState{}}
+	m.crash.program = tea.NewProgram(m, append(opts, bubbletea.MakeOptions(s)...)...)
+	return m.crash.program
+}
+
+// safeMiddleware é o bubbletea.Middleware com safeProgramHandler.
+func safeMiddleware() wish.Middleware {
+	return bubbletea.MiddlewareWithProgramHandler(safeProgramHandler, termenv.Ascii)
+}
+
+func (m safeModel) Init() (cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			cmd = m.fail("Init", r)
+		}
+	}()
+	return m.inner.Init()
+}
+
+func (m safeModel) Update(msg tea.Msg) (result tea.Model, cmd tea.Cmd) {
+	if m.crash.failed.Load() {
+		return m, tea.Quit
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, cmd = m, m.fail("Update", r)
+		}
+	}()
+	m.inner, cmd = m.inner.Update(msg)
+	return m, cmd
+}
+
+func (m safeModel) View() (view string) {
+	if m.crash.failed.Load() {
+		return crashMessage
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.fail("View", r)
+			// View roda no loop do programa: Quit precisa vir de fora dele
+			go m.crash.program.Quit()
+			view = crashMessage
+		}
+	}()
+	return m.inner.View()
+}
+
+// fail loga o panic r, vindo do método method, com o contexto da sessão,
+// marca o model como falho e retorna o Cmd que encerra o programa.
+func (m safeModel) fail(method string, r any) tea.Cmd {
+	m.crash.failed.Store(true)
+	log.Error("Panic na sessão", "method", method, "user", m.sess.User(), "remote", m.sess.RemoteAddr(),
+		"panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+	return tea.Quit
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/muesli/termenv"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// panicModel entra em panic no método panicIn ("Init", "Update" ou "View").
+type panicModel struct {
+	panicIn string
+}
+
+func (m panicModel) Init() tea.Cmd {
+	if m.panicIn == "Init" {
+		panic("boom in Init")
+	}
+	return nil
+}
+
+func (m panicModel) Update(tea.Msg) (tea.Model, tea.Cmd) {
+	if m.panicIn == "Update" {
+		panic("boom in Update")
+	}
+	return m, nil
+}
+
+func (m panicModel) View() string {
+	if m.panicIn == "View" {
+		panic("boom in View")
+	}
+	return "ok"
+}
+
+// fakeSession é uma sessão SSH com só o que safeModel usa para logar.
+type fakeSession struct {
+	ssh.Session
+}
+
+func (fakeSession) User() string         { return "visitante" }
+func (fakeSession) RemoteAddr() net.Addr { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222} }
+
+// newSafeModel envolve inner num safeModel com um programa que nunca
+// roda, para o Quit pedido pelo View não ficar preso.
+func newSafeModel(t *testing.T, inner tea.Model) safeModel {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m := safeModel{inner: inner, sess: fakeSession{}, crash: &crashState{}}
+	m.crash.program = tea.NewProgram(m, tea.WithContext(ctx))
+	return m
+}
+
+// isQuit informa se cmd encerra o programa.
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestSafeModelRecovers(t *testing.T) {
+	for _, method := range []string{"Init", "Update", "View"} {
+		t.Run(method, func(t *testing.T) {
+			m := newSafeModel(t, panicModel{panicIn: method})
+
+			switch method {
+			case "Init":
+				if !isQuit(m.Init()) {
+					t.Error("Init after a panic did not quit")
+				}
+			case "Update":
+				next, cmd := m.Update(nil)
+				if !isQuit(cmd) {
+					t.Error("Update after a panic did not quit")
+				}
+				m = next.(safeModel)
+			case "View":
+				if view := m.View(); view != crashMessage {
+					t.Errorf("View = %q, want the crash message", view)
+				}
+			}
+
+			// Depois do panic, a sessão só mostra o aviso e sai
+			if !m.crash.failed.Load() {
+				t.Fatal("panic not recorded")
+			}
+			if view := m.View(); view != crashMessage {
+				t.Errorf("View after the panic = %q, want the crash message", view)
+			}
+			if _, cmd := m.Update(nil); !isQuit(cmd) {
+				t.Error("Update after the panic did not quit")
+			}
+		})
+	}
+}
+
+func TestSafeModelPassesThrough(t *testing.T) {
+	m := newSafeModel(t, panicModel{})
+	if cmd := m.Init(); cmd != nil {
+		t.Error("Init returned a command")
+	}
+	if _, cmd := m.Update(nil); cmd != nil {
+		t.Error("Update returned a command")
+	}
+	if view := m.View(); view != "ok" {
+		t.Errorf("View = %q, want the inner view", view)
+	}
+}
+
+// TestPanickingSessionKeepsServerUp abre uma sessão cujo model entra em
+// panic no View: a sessão recebe o aviso e fecha, e o servidor continua
+// aceitando conexões.
+func TestPanickingSessionKeepsServerUp(t *testing.T) {
+	handler := func(s ssh.Session) *tea.Program {
+		return newSafeProgram(s, panicModel{panicIn: "View"})
+	}
+	srv, err := wish.NewServer(
+		wish.WithHostKeyPath(filepath.Join(t.TempDir(), "host_key")),
+		wish.WithMiddleware(bubbletea.MiddlewareWithProgramHandler(handler, termenv.Ascii)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	connect := func() string {
+		t.Helper()
+		client, err := gossh.Dial("tcp", ln.Addr().String(), &gossh.ClientConfig{
+			User:            "visitante",
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+			Timeout:         5 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		if err := session.RequestPty("xterm", 24, 80, gossh.TerminalModes{}); err != nil {
+			t.Fatal(err)
+		}
+		stdout, err := session.StdoutPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Shell(); err != nil {
+			t.Fatal(err)
+		}
+
+		out := make(chan []byte, 1)
+		go func() {
+			b, _ := io.ReadAll(stdout)
+			out <- b
+		}()
+		select {
+		case b := <-out:
+			return string(b)
+		case <-time.After(5 * time.Second):
+			t.Fatal("session did not close after the panic")
+			return ""
+		}
+	}
+
+	for i := range 2 {
+		if out := connect(); !strings.Contains(out, strings.TrimSpace(crashMessage)) {
+			t.Errorf("session %d got %q, want the crash message", i+1, out)
+		}
+	}
+}
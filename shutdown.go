@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// closer é uma rotina de limpeza executada no encerramento do servidor.
+type closer struct {
+	name string
+	fn   func(context.Context) error
+}
+
+var (
+	closersMu sync.Mutex
+	closers   []closer
+)
+
+// onShutdown registra fn para rodar no encerramento, depois que o servidor
+// SSH parar de aceitar sessões. Recursos de longa duração (provider,
+// servidor HTTP, caches) se registram aqui ao serem criados.
+func onShutdown(name string, fn func(context.Context) error) {
+	closersMu.Lock()
+	defer closersMu.Unlock()
+	closers = append(closers, closer{name: name, fn: fn})
+}
+
+// runClosers executa as rotinas registradas em ordem inversa ao registro,
+// para que um recurso seja encerrado antes daqueles de que depende.
+// Erros são logados e não interrompem as demais rotinas.
+func runClosers(ctx context.Context) {
+	closersMu.Lock()
+	pending := closers
+	closers = nil
+	closersMu.Unlock()
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		c := pending[i]
+		log.Debug("Encerrando recurso", "name", c.name)
+		if err := c.fn(ctx); err != nil {
+			log.Error("Erro ao encerrar recurso", "name", c.name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+
+	"github.com/charmbracelet/log"
+)
+
+// listenTrackSocket abre um socket Unix em path que, a cada conexão,
+// escreve a música atual de p em JSON (null se não houver) e fecha.
+// Serve para integrações locais, como barras de status, sem passar pelo
+// SSH. Um arquivo que sobrou de uma execução anterior é removido antes.
+func listenTrackSocket(path string, p *Provider) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	go serveTrackSocket(ln, p)
+	return ln, nil
+}
+
+// serveTrackSocket atende conexões até ln ser fechado.
+func serveTrackSocket(ln net.Listener, p *Provider) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error("Erro no socket da música atual", "error", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			if err := json.NewEncoder(conn).Encode(p.Current().track); err != nil {
+				log.Debug("Erro ao escrever no socket da música atual", "error", err)
+			}
+		}()
+	}
+}
+
+// closeTrackSocket fecha ln. O listener Unix do pacote net já remove o
+// arquivo ao fechar, mas a remoção explícita cobre o caso de ele ter sido
+// criado por outro caminho.
+func closeTrackSocket(ln net.Listener, path string) func(context.Context) error {
+	return func(context.Context) error {
+		err := ln.Close()
+		if rmErr := os.Remove(path); rmErr != nil && !errors.Is(rmErr, fs.ErrNotExist) {
+			err = errors.Join(err, rmErr)
+		}
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ssh-portfolio/spotify"
+)
+
+// readTrackSocket conecta em path e devolve tudo o que o socket escreveu.
+func readTrackSocket(t *testing.T, path string) string {
+	t.Helper()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestTrackSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.sock")
+	p := newTestProvider()
+	ln, err := listenTrackSocket(path, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeTrackSocket(ln, path)(t.Context())
+
+	if got := strings.TrimSpace(readTrackSocket(t, path)); got != "null" {
+		t.Errorf("without a track got %q, want null", got)
+	}
+
+	p.publish(trackUpdate{track: &spotify.Track{Name: "Song", Artist: "Band"}})
+	var track spotify.Track
+	if err := json.Unmarshal([]byte(readTrackSocket(t, path)), &track); err != nil {
+		t.Fatal(err)
+	}
+	if track.Name != "Song" || track.Artist != "Band" {
+		t.Errorf("got %+v, want Song by Band", track)
+	}
+}
+
+func TestTrackSocketReplacesStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.sock")
+	first, err := listenTrackSocket(path, newTestProvider())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simula um arquivo que sobrou: o listener some sem apagar o socket.
+	first.(*net.UnixListener).SetUnlinkOnClose(false)
+	first.Close()
+
+	ln, err := listenTrackSocket(path, newTestProvider())
+	if err != nil {
+		t.Fatalf("stale socket file was not replaced: %v", err)
+	}
+	closeTrackSocket(ln, path)(t.Context())
+	if _, err := net.Dial("unix", path); err == nil {
+		t.Error("socket still accepts connections after close")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sparkLevels são os níveis do sparkline, do menor para o maior.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+const (
+	// activityWindow é o período coberto pelo sparkline de atividade.
+	activityWindow = 24 * time.Hour
+
+	// activityBuckets é o número de colunas do sparkline (uma por hora).
+	activityBuckets = 24
+)
+
+// activityLabel vem antes do sparkline quando há espaço.
+const activityLabel = "últimas 24h "
+
+// activityLine é a linha do sparkline no rodapé, cabendo em width: com o
+// rótulo, só o sparkline e, se nem ele couber, vazia (cortado, o gráfico
+// perderia as horas mais recentes).
+func activityLine(spark string, width int) string {
+	switch {
+	case spark == "":
+		return ""
+	case lipgloss.Width(activityLabel+spark) <= width:
+		return activityLabel + spark
+	case lipgloss.Width(spark) <= width:
+		return spark
+	}
+	return ""
+}
+
+// sparkline agrupa as reproduções em buckets de tempo iguais cobrindo
+// [end-window, end) e desenha um caractere por bucket, proporcional ao
+// bucket mais cheio. Reproduções fora da janela são ignoradas.
+// Retorna "" se não houver nenhuma reprodução na janela.
+func sparkline(plays []time.Time, end time.Time, window time.Duration, buckets int) string {
+	if buckets <= 0 || window <= 0 {
+		return ""
+	}
+
+	start := end.Add(-window)
+	counts := make([]int, buckets)
+	peak := 0
+	for _, t := range plays {
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		i := int(t.Sub(start) * time.Duration(buckets) / window)
+		counts[i]++
+		peak = max(peak, counts[i])
+	}
+
+	if peak == 0 {
+		return ""
+	}
+
+	out := make([]rune, buckets)
+	top := len(sparkLevels) - 1
+	for i, n := range counts {
+		// Buckets com alguma reprodução nunca ficam no nível zero
+		level := n * top / peak
+		if n > 0 && level == 0 {
+			level = 1
+		}
+		out[i] = sparkLevels[level]
+	}
+	return string(out)
+}
+
+// playTimes extrai os horários de reprodução do histórico.
+func playTimes(history []*spotify.Track) []time.Time {
+	times := make([]time.Time, 0, len(history))
+	for _, t := range history {
+		if !t.PlayedAt.IsZero() {
+			times = append(times, t.PlayedAt)
+		}
+	}
+	return times
+}
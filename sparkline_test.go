@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSparkline(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(h ...float64) []time.Time {
+		var out []time.Time
+		for _, x := range h {
+			out = append(out, end.Add(-time.Duration(x*float64(time.Hour))))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		plays []time.Time
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"outside window", hoursAgo(5, 30), ""},
+		{"one bucket", hoursAgo(0.5), "▁▁▁█"},
+		{"proportional", hoursAgo(3.5, 0.5, 0.2), "▄▁▁█"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.plays, end, 4*time.Hour, 4); got != tt.want {
+				t.Errorf("sparkline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivityLine(t *testing.T) {
+	spark := strings.Repeat("▅", activityBuckets)
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{80, activityLabel + spark},
+		{lipgloss.Width(activityLabel + spark), activityLabel + spark},
+		{30, spark},
+		{activityBuckets, spark},
+		{activityBuckets - 1, ""},
+	}
+	for _, tt := range tests {
+		if got := activityLine(spark, tt.width); got != tt.want {
+			t.Errorf("activityLine(width %d) = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+	if got := activityLine("", 80); got != "" {
+		t.Errorf("activityLine(empty) = %q, want empty", got)
+	}
+}
+
+func TestFooterFitsNarrowTerminal(t *testing.T) {
+	now := time.Now()
+	var history []*spotify.Track
+	for i := range 30 {
+		history = append(history, &spotify.Track{Name: "Song", PlayedAt: now.Add(-time.Duration(i) * 40 * time.Minute)})
+	}
+
+	for width := 10; width <= 40; width++ {
+		m := newModel(width, 40, nil, false)
+		m.history = history
+		m.notice = "aviso de troca: " + strings.Repeat("x", 40)
+		for i, line := range strings.Split(m.View(), "\n") {
+			if w := lipgloss.Width(line); w > width {
+				t.Fatalf("width %d: line %d is %d columns: %q", width, i, w, line)
+			}
+		}
+	}
+}
@@ -26,7 +26,7 @@ var (
 	clientID     = os.Getenv("SPOTIFY_CLIENT_ID")
 	clientSecret = os.Getenv("SPOTIFY_CLIENT_SECRET")
 	redirectURI  = "http://127.0.0.1:8888/callback"
-	scopes       = "user-read-currently-playing user-read-recently-played"
+	scopes       = "user-read-currently-playing user-read-recently-played user-read-playback-state user-modify-playback-state"
 )
 
 type tokenResponse struct {
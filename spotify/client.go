@@ -6,17 +6,43 @@ package spotify
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/log"
 )
 
+// ErrEmptyAccessToken indica que o Spotify respondeu ao refresh sem access token.
+var ErrEmptyAccessToken = errors.New("token response has empty access_token")
+
+// StatusError é a resposta da API com status diferente de 200 e 204.
+type StatusError struct {
+	Status int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("spotify API error: %d", e.Status)
+}
+
+// IsServerError informa se err é uma falha do lado do Spotify (5xx).
+// Essas falhas costumam atingir um endpoint por vez, então outro endpoint
+// (ex.: o histórico no lugar da música atual) ainda pode responder.
+func IsServerError(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Status >= 500
+}
+
 // Client é o cliente HTTP para a Spotify Web API.
 // Thread-safe através de mutex para acesso ao access token.
 //
@@ -33,15 +59,64 @@ type Client struct {
 	tokenExpiry  time.Time      // Quando o access token expira
 	mu           sync.RWMutex   // Protege accessToken e tokenExpiry
 	httpClient   *http.Client   // Cliente HTTP com timeout
+
+	tokenCachePath string // Arquivo de cache do access token; vazio desliga (WithTokenCache)
+	noRecent       bool   // Desliga o fallback para o histórico (WithRecentlyPlayedFallback)
+	scopes         string // Scopes concedidos ao token, separados por espaço; vazio se desconhecido
+
+	contextMu    sync.Mutex
+	contextNames map[string]string // Nomes de contexto já buscados, por href
+	artistImages map[string]string // URLs de foto de artista já buscadas, por ID
 }
 
 // Track representa uma música do Spotify.
 type Track struct {
-	Name       string // Nome da música
-	Artist     string // Nome do artista principal
-	Album      string // Nome do álbum
-	ArtworkURL string // URL da capa do álbum (640x640)
-	IsPlaying  bool   // true se está tocando agora
+	Name       string   `json:"name"`                 // Nome da música
+	Artist     string   `json:"artist"`               // Nome do artista principal
+	Artists    []string `json:"artists,omitempty"`    // Todos os artistas, o principal primeiro
+	ArtistIDs  []string `json:"artist_ids,omitempty"` // IDs dos artistas, na mesma ordem de Artists
+	Album      string   `json:"album"`                // Nome do álbum
+	ArtworkURL string   `json:"artwork_url"`          // URL da capa do álbum (640x640)
+	URL        string   `json:"url,omitempty"`        // Link da música no Spotify, vazio se desconhecido
+	IsPlaying  bool     `json:"is_playing"`           // true se está tocando agora
+
+	TrackNumber int    `json:"track_number"`           // Posição da música no disco (1-based)
+	DiscNumber  int    `json:"disc_number"`            // Disco do álbum (1-based; > 1 só em álbuns com vários discos)
+	AlbumTracks int    `json:"album_tracks"`           // Total de músicas do álbum
+	ContextType string `json:"context_type,omitempty"` // Tipo do contexto tocando (album, playlist...), vazio se desconhecido
+	ContextURI  string `json:"context_uri,omitempty"`  // URI do contexto (spotify:playlist:...)
+	ContextName string `json:"context_name,omitempty"` // Nome da playlist/álbum/artista do contexto, vazio se desconhecido
+	IsPlayable  bool   `json:"is_playable"`            // false se a música não pode tocar no mercado do usuário
+
+	ProgressMs int `json:"progress_ms"` // Posição da reprodução no momento da busca (só na música atual)
+	DurationMs int `json:"duration_ms"` // Duração da música; 0 se desconhecida
+
+	PlayedAt time.Time `json:"played_at,omitzero"` // Quando foi tocada (só em itens do histórico)
+
+	// Disallows são as ações do player que o Spotify não permite agora
+	// (ex.: "skipping_prev" no começo de um contexto), em ordem alfabética.
+	// Só na música atual; vazio se tudo é permitido ou desconhecido.
+	Disallows []string `json:"disallows,omitempty"`
+}
+
+// Ações do player em Track.Disallows usadas pelos controles do dono.
+// A API tem outras (seeking, toggling_shuffle...), que passam sem tradução.
+const (
+	ActionPausing      = "pausing"
+	ActionResuming     = "resuming"
+	ActionSkippingNext = "skipping_next"
+	ActionSkippingPrev = "skipping_prev"
+)
+
+// Allows informa se action não está em t.Disallows.
+func (t *Track) Allows(action string) bool {
+	return !slices.Contains(t.Disallows, action)
+}
+
+// Queue representa a fila de reprodução do usuário.
+// O Spotify só expõe as próximas músicas, não o tamanho total do contexto.
+type Queue struct {
+	Next []*Track // Próximas músicas, na ordem em que vão tocar
 }
 
 // tokenResponse é a resposta do endpoint /api/token.
@@ -49,125 +124,339 @@ type tokenResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"` // Segundos até expirar (~3600)
+	Scope       string `json:"scope"`      // Scopes concedidos, separados por espaço
+}
+
+// trackItem é o objeto de música retornado pelos endpoints do player.
+type trackItem struct {
+	Name         string `json:"name"`
+	TrackNumber  int    `json:"track_number"`
+	DiscNumber   int    `json:"disc_number"`
+	DurationMs   int    `json:"duration_ms"`
+	IsPlayable   *bool  `json:"is_playable"` // Só presente quando a request informa market
+	ExternalURLs struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+	Album struct {
+		Name        string  `json:"name"`
+		TotalTracks int     `json:"total_tracks"`
+		Images      []image `json:"images"`
+	} `json:"album"`
+	Artists []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"artists"`
+}
+
+// image é uma das versões (tamanhos) de uma imagem da API.
+// Width e Height vêm nulos (0) em algumas imagens enviadas por usuários.
+type image struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 // currentlyPlayingResponse é a resposta do endpoint /me/player/currently-playing.
 type currentlyPlayingResponse struct {
-	IsPlaying bool `json:"is_playing"`
-	Item      *struct {
-		Name  string `json:"name"`
-		Album struct {
-			Name   string `json:"name"`
-			Images []struct {
-				URL string `json:"url"`
-			} `json:"images"`
-		} `json:"album"`
-		Artists []struct {
-			Name string `json:"name"`
-		} `json:"artists"`
-	} `json:"item"`
+	IsPlaying  bool       `json:"is_playing"`
+	ProgressMs int        `json:"progress_ms"`
+	Item       *trackItem `json:"item"`
+	Context    *struct {
+		Type string `json:"type"` // album, playlist, artist ou show
+		URI  string `json:"uri"`  // ex.: spotify:playlist:37i9dQZF1DXcBWIGoYBM5M
+		Href string `json:"href"` // Endpoint da API com os detalhes do contexto
+	} `json:"context"`
+	Actions struct {
+		// Disallows lista ações indisponíveis como true; ações permitidas
+		// costumam ser omitidas, mas podem vir como false
+		Disallows map[string]bool `json:"disallows"`
+	} `json:"actions"`
+}
+
+// playHistoryItem é uma entrada do histórico de reprodução.
+type playHistoryItem struct {
+	Track    trackItem `json:"track"`
+	PlayedAt time.Time `json:"played_at"`
 }
 
 // recentlyPlayedResponse é a resposta do endpoint /me/player/recently-played.
 type recentlyPlayedResponse struct {
-	Items []struct {
-		Track struct {
-			Name  string `json:"name"`
-			Album struct {
-				Name   string `json:"name"`
-				Images []struct {
-					URL string `json:"url"`
-				} `json:"images"`
-			} `json:"album"`
-			Artists []struct {
-				Name string `json:"name"`
-			} `json:"artists"`
-		} `json:"track"`
-	} `json:"items"`
+	Items   []playHistoryItem `json:"items"`
+	Cursors *struct {
+		After  string `json:"after"`
+		Before string `json:"before"`
+	} `json:"cursors"`
+}
+
+// queueResponse é a resposta do endpoint /me/player/queue.
+type queueResponse struct {
+	Queue []trackItem `json:"queue"`
+}
+
+// defaultTimeout é o timeout padrão das requests HTTP do cliente.
+const defaultTimeout = 10 * time.Second
+
+// newTransport cria o transporte compartilhado pelas chamadas da API e do
+// token. São só dois hosts (api.spotify.com e accounts.spotify.com), então
+// poucas conexões ociosas por host bastam; mantê-las abertas evita um novo
+// handshake TLS a cada ciclo de polling, e o HTTP/2 multiplexa a busca da
+// música, da fila e do histórico numa única conexão.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	t.MaxIdleConns = 10
+	t.MaxIdleConnsPerHost = 4
+	t.IdleConnTimeout = 90 * time.Second
+	return t
+}
+
+// closeBody descarta o resto do corpo de resp antes de fechá-lo: no
+// HTTP/1.1, uma conexão com corpo não lido não volta para o pool.
+func closeBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// Option configura um Client em NewClient.
+type Option func(*Client)
+
+// WithTimeout define o timeout de cada request HTTP (token e API).
+// Valores não positivos são ignorados e o padrão de 10s é mantido.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			log.Warn("Ignoring non-positive HTTP timeout", "timeout", d, "default", defaultTimeout)
+			return
+		}
+		c.httpClient.Timeout = d
+	}
+}
+
+// WithProxy faz todas as requests (token e API) passarem pelo proxy em
+// proxyURL. Sem esta opção, o transporte respeita HTTP_PROXY, HTTPS_PROXY
+// e NO_PROXY, como o transporte padrão.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.httpClient.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithTransport troca o transporte de todas as requests (token e API),
+// por exemplo para instrumentá-las ou apontá-las para um servidor de
+// testes. Substitui o de WithProxy.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// WithRecentlyPlayedFallback liga ou desliga o uso do histórico
+// (user-read-recently-played) quando nada está tocando. Ligado por padrão;
+// desligue em deploys cujo token não tem esse scope, para não gerar um
+// erro a cada ciclo de polling.
+func WithRecentlyPlayedFallback(enabled bool) Option {
+	return func(c *Client) {
+		c.noRecent = !enabled
+	}
+}
+
+// RecentlyPlayedFallback informa se o histórico pode ser consultado
+// (ver WithRecentlyPlayedFallback).
+func (c *Client) RecentlyPlayedFallback() bool {
+	return !c.noRecent
 }
 
 // NewClient cria um novo cliente Spotify.
 // Parâmetros obtidos no Spotify Developer Dashboard + fluxo OAuth.
-func NewClient(clientID, clientSecret, refreshToken string) *Client {
-	return &Client{
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		refreshToken: refreshToken,
-		httpClient:   &http.Client{Timeout: 10 * time.Second},
+func NewClient(clientID, clientSecret, refreshToken string, opts ...Option) *Client {
+	c := &Client{
+		clientID:     trimCredential("client ID", clientID),
+		clientSecret: trimCredential("client secret", clientSecret),
+		refreshToken: trimCredential("refresh token", refreshToken),
+		httpClient:   &http.Client{Timeout: defaultTimeout, Transport: newTransport()},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// trimCredential remove espaços e quebras de linha em volta de uma
+// credencial, erro comum ao copiá-la de um arquivo, e avisa quando o
+// valor mudou. O valor em si nunca é logado.
+func trimCredential(name, value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed != value {
+		log.Warn("Trimmed surrounding whitespace from credential", "credential", name)
+	}
+	return trimmed
 }
 
 // GetCurrentlyPlaying retorna a música tocando agora.
 // Retorna nil se nada estiver tocando (status 204).
+// Usa market=from_token para que a API informe is_playable.
 //
 // Endpoint: GET /v1/me/player/currently-playing
 // Scope necessário: user-read-currently-playing
 func (c *Client) GetCurrentlyPlaying() (*Track, error) {
 	log.Debug("Fetching currently playing track")
 
-	if err := c.ensureValidToken(); err != nil {
-		log.Error("Failed to get valid token", "error", err)
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
-	}
-
-	req, err := http.NewRequest("GET", "https://api.spotify.com/v1/me/player/currently-playing", nil)
+	var data currentlyPlayingResponse
+	status, err := c.get("https://api.spotify.com/v1/me/player/currently-playing?market=from_token", &data)
 	if err != nil {
-		log.Error("Failed to create request", "error", err)
 		return nil, err
 	}
 
-	c.mu.RLock()
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	c.mu.RUnlock()
+	if status == http.StatusNoContent {
+		log.Debug("No content - nothing playing")
+		return nil, nil
+	}
 
-	log.Debug("Sending request to Spotify API", "url", req.URL.String())
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		log.Error("Request failed", "error", err)
-		return nil, err
+	if data.Item == nil {
+		log.Debug("No item in response")
+		return nil, nil
 	}
-	defer resp.Body.Close()
 
-	log.Debug("Received response", "status", resp.StatusCode)
+	track := newTrack(data.Item)
+	track.IsPlaying = data.IsPlaying
+	track.ProgressMs = data.ProgressMs
+	track.Disallows = disallowed(data.Actions.Disallows)
+	if data.Context != nil {
+		track.ContextType = data.Context.Type
+		track.ContextURI = data.Context.URI
+		track.ContextName = c.contextName(data.Context.Href)
+	}
 
-	if resp.StatusCode == http.StatusNoContent {
-		log.Debug("No content - nothing playing")
-		return nil, nil
+	log.Info("Got currently playing", "track", track.Name, "artist", track.Artist, "playing", track.IsPlaying)
+	return track, nil
+}
+
+// disallowed retorna as ações marcadas como true em disallows, ordenadas
+// para que a mesma resposta sempre gere o mesmo Track.
+func disallowed(disallows map[string]bool) []string {
+	var actions []string
+	for action, blocked := range disallows {
+		if blocked {
+			actions = append(actions, action)
+		}
 	}
+	slices.Sort(actions)
+	return actions
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Error("Spotify API error", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("spotify API error: %d", resp.StatusCode)
+// maxContextNames limita o cache de nomes de contexto.
+const maxContextNames = 100
+
+// contextName retorna o nome do contexto (playlist, álbum, artista) em href.
+// Os nomes são cacheados: a mesma playlist toca por vários ciclos de
+// polling e o nome raramente muda. Falhas temporárias retornam "" sem
+// cachear, para tentar de novo no próximo ciclo.
+//
+// Endpoint: GET /v1/playlists/{id}, /v1/albums/{id} ou /v1/artists/{id}
+// Scope necessário: playlist-read-private (só para playlists privadas)
+func (c *Client) contextName(href string) string {
+	if href == "" {
+		return ""
 	}
 
-	var data currentlyPlayingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Error("Failed to decode response", "error", err)
-		return nil, err
+	c.contextMu.Lock()
+	name, ok := c.contextNames[href]
+	c.contextMu.Unlock()
+	if ok {
+		return name
 	}
 
-	if data.Item == nil {
-		log.Debug("No item in response")
-		return nil, nil
+	reqURL := href
+	if strings.Contains(href, "/playlists/") {
+		// Playlists incluem todas as músicas na resposta; só o nome interessa
+		reqURL += "?fields=name"
 	}
 
-	track := &Track{
-		Name:      data.Item.Name,
-		Album:     data.Item.Album.Name,
-		IsPlaying: data.IsPlaying,
+	var data struct {
+		Name string `json:"name"`
+	}
+	status, err := c.get(reqURL, &data)
+	if err != nil {
+		log.Debug("Failed to fetch context name", "href", href, "error", err)
+		// 404 é permanente (ex.: playlists geradas pelo Spotify): cacheia o vazio
+		if status != http.StatusNotFound {
+			return ""
+		}
+	}
+	name = sanitize(data.Name)
+
+	c.contextMu.Lock()
+	if c.contextNames == nil || len(c.contextNames) >= maxContextNames {
+		c.contextNames = make(map[string]string)
 	}
+	c.contextNames[href] = name
+	c.contextMu.Unlock()
+
+	return name
+}
 
-	if len(data.Item.Artists) > 0 {
-		track.Artist = data.Item.Artists[0].Name
+// GetArtistImages retorna a URL da maior foto de cada artista em ids,
+// na mesma ordem ("" para artistas sem foto). As URLs são cacheadas junto
+// com os nomes de contexto, já que mudam raramente.
+//
+// Endpoint: GET /v1/artists?ids=...
+// Scope necessário: nenhum
+func (c *Client) GetArtistImages(ids []string) ([]string, error) {
+	c.contextMu.Lock()
+	var missing []string
+	for _, id := range ids {
+		if _, ok := c.artistImages[id]; !ok && id != "" {
+			missing = append(missing, id)
+		}
 	}
+	c.contextMu.Unlock()
 
-	if len(data.Item.Album.Images) > 0 {
-		track.ArtworkURL = data.Item.Album.Images[0].URL
+	if len(missing) > 0 {
+		var data struct {
+			Artists []*struct {
+				ID     string  `json:"id"`
+				Images []image `json:"images"`
+			} `json:"artists"`
+		}
+		if _, err := c.get("https://api.spotify.com/v1/artists?ids="+url.QueryEscape(strings.Join(missing, ",")), &data); err != nil {
+			return nil, err
+		}
+
+		c.contextMu.Lock()
+		if c.artistImages == nil || len(c.artistImages) >= maxContextNames {
+			c.artistImages = make(map[string]string)
+		}
+		for _, a := range data.Artists {
+			if a == nil {
+				continue
+			}
+			img, _ := largestImage(a.Images)
+			c.artistImages[a.ID] = img.URL
+		}
+		c.contextMu.Unlock()
 	}
 
-	log.Info("Got currently playing", "track", track.Name, "artist", track.Artist, "playing", track.IsPlaying)
-	return track, nil
+	c.contextMu.Lock()
+	defer c.contextMu.Unlock()
+	urls := make([]string, len(ids))
+	for i, id := range ids {
+		urls[i] = c.artistImages[id]
+	}
+	return urls, nil
+}
+
+// GetDisplayName retorna o nome de exibição do dono da conta.
+//
+// Endpoint: GET /v1/me
+// Scope necessário: nenhum
+func (c *Client) GetDisplayName() (string, error) {
+	var data struct {
+		DisplayName string `json:"display_name"`
+	}
+	if _, err := c.get("https://api.spotify.com/v1/me", &data); err != nil {
+		return "", err
+	}
+	return sanitize(data.DisplayName), nil
 }
 
 // GetRecentlyPlayed retorna a última música tocada.
@@ -178,79 +467,383 @@ func (c *Client) GetCurrentlyPlaying() (*Track, error) {
 func (c *Client) GetRecentlyPlayed() (*Track, error) {
 	log.Debug("Fetching recently played track")
 
+	var data recentlyPlayedResponse
+	if _, err := c.get("https://api.spotify.com/v1/me/player/recently-played?limit=1", &data); err != nil {
+		return nil, err
+	}
+
+	if len(data.Items) == 0 {
+		log.Debug("No items in recently played")
+		return nil, nil
+	}
+
+	track := newPlayedTrack(&data.Items[0])
+
+	log.Info("Got recently played", "track", track.Name, "artist", track.Artist)
+	return track, nil
+}
+
+// maxHistoryPages limita quantas páginas GetRecentlyPlayedSince percorre.
+const maxHistoryPages = 5
+
+// GetRecentlyPlayedSince retorna as músicas tocadas depois de since,
+// da mais antiga para a mais recente, com PlayedAt preenchido.
+// A API devolve no máximo 50 itens por página; percorre até
+// maxHistoryPages páginas usando o cursor after.
+//
+// Endpoint: GET /v1/me/player/recently-played?after=<ms>&limit=50
+// Scope necessário: user-read-recently-played
+func (c *Client) GetRecentlyPlayedSince(since time.Time) ([]*Track, error) {
+	log.Debug("Fetching recently played history", "since", since)
+
+	var tracks []*Track
+	after := strconv.FormatInt(since.UnixMilli(), 10)
+
+	for page := 0; page < maxHistoryPages; page++ {
+		var data recentlyPlayedResponse
+		if _, err := c.get("https://api.spotify.com/v1/me/player/recently-played?limit=50&after="+after, &data); err != nil {
+			return nil, err
+		}
+
+		for i := range data.Items {
+			tracks = append(tracks, newPlayedTrack(&data.Items[i]))
+		}
+
+		if len(data.Items) < 50 || data.Cursors == nil || data.Cursors.After == "" || data.Cursors.After == after {
+			break
+		}
+		after = data.Cursors.After
+	}
+
+	// Cada página vem da mais recente para a mais antiga
+	sort.SliceStable(tracks, func(i, j int) bool {
+		return tracks[i].PlayedAt.Before(tracks[j].PlayedAt)
+	})
+
+	log.Debug("Got recently played history", "count", len(tracks))
+	return tracks, nil
+}
+
+// GetQueue retorna as próximas músicas da fila de reprodução.
+// Retorna nil se nada estiver tocando (status 204).
+//
+// Endpoint: GET /v1/me/player/queue
+// Scope necessário: user-read-currently-playing, user-read-playback-state
+func (c *Client) GetQueue() (*Queue, error) {
+	log.Debug("Fetching playback queue")
+
+	var data queueResponse
+	status, err := c.get("https://api.spotify.com/v1/me/player/queue", &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNoContent {
+		log.Debug("No content - nothing queued")
+		return nil, nil
+	}
+
+	queue := &Queue{}
+	for i := range data.Queue {
+		queue.Next = append(queue.Next, newTrack(&data.Queue[i]))
+	}
+
+	log.Debug("Got queue", "length", len(queue.Next))
+	return queue, nil
+}
+
+// get faz um GET autenticado em url e decodifica o JSON da resposta em v.
+// Retorna o status HTTP; em 204 (sem conteúdo) v não é tocado.
+// Qualquer status diferente de 200 e 204 vira erro.
+func (c *Client) get(url string, v any) (status int, err error) {
+	return c.do("GET", url, v)
+}
+
+// do faz uma chamada autenticada à API com method e decodifica a
+// resposta em v. Com v nil (comandos do player), qualquer 2xx é sucesso
+// e o corpo é descartado.
+func (c *Client) do(method, url string, v any) (status int, err error) {
+	refreshed := !c.tokenValid()
+	start := time.Now()
+	defer func() {
+		logCall(url, status, time.Since(start), refreshed, err)
+	}()
+
 	if err := c.ensureValidToken(); err != nil {
 		log.Error("Failed to get valid token", "error", err)
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+		return 0, fmt.Errorf("failed to get valid token: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", "https://api.spotify.com/v1/me/player/recently-played?limit=1", nil)
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		log.Error("Failed to create request", "error", err)
-		return nil, err
+		return 0, err
 	}
 
 	c.mu.RLock()
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	c.mu.RUnlock()
 
-	log.Debug("Sending request to Spotify API", "url", req.URL.String())
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		log.Error("Request failed", "error", err)
-		return nil, err
+		return 0, err
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp)
 
-	log.Debug("Received response", "status", resp.StatusCode)
+	if resp.StatusCode == http.StatusNoContent || v == nil && resp.StatusCode/100 == 2 {
+		return resp.StatusCode, nil
+	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		log.Error("Spotify API error", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("spotify API error: %d", resp.StatusCode)
+		return resp.StatusCode, &StatusError{Status: resp.StatusCode}
 	}
 
-	var data recentlyPlayedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
 		log.Error("Failed to decode response", "error", err)
-		return nil, err
+		return resp.StatusCode, err
 	}
 
-	if len(data.Items) == 0 {
-		log.Debug("No items in recently played")
-		return nil, nil
-	}
+	return resp.StatusCode, nil
+}
+
+// Play retoma a reprodução no dispositivo ativo.
+//
+// Endpoint: PUT /v1/me/player/play
+// Scope necessário: user-modify-playback-state
+func (c *Client) Play() error {
+	_, err := c.do("PUT", "https://api.spotify.com/v1/me/player/play", nil)
+	return err
+}
+
+// Pause pausa a reprodução no dispositivo ativo.
+//
+// Endpoint: PUT /v1/me/player/pause
+// Scope necessário: user-modify-playback-state
+func (c *Client) Pause() error {
+	_, err := c.do("PUT", "https://api.spotify.com/v1/me/player/pause", nil)
+	return err
+}
+
+// Next pula para a próxima música da fila.
+//
+// Endpoint: POST /v1/me/player/next
+// Scope necessário: user-modify-playback-state
+func (c *Client) Next() error {
+	_, err := c.do("POST", "https://api.spotify.com/v1/me/player/next", nil)
+	return err
+}
+
+// Previous volta para a música anterior.
+//
+// Endpoint: POST /v1/me/player/previous
+// Scope necessário: user-modify-playback-state
+func (c *Client) Previous() error {
+	_, err := c.do("POST", "https://api.spotify.com/v1/me/player/previous", nil)
+	return err
+}
 
-	item := data.Items[0].Track
+// newTrack converte um item da API em Track.
+// IsPlaying fica false; cabe ao chamador preencher o estado de reprodução.
+func newTrack(item *trackItem) *Track {
 	track := &Track{
-		Name:      item.Name,
-		Album:     item.Album.Name,
-		IsPlaying: false,
+		Name:        sanitize(item.Name),
+		Album:       sanitize(item.Album.Name),
+		URL:         item.ExternalURLs.Spotify,
+		DurationMs:  item.DurationMs,
+		TrackNumber: item.TrackNumber,
+		DiscNumber:  item.DiscNumber,
+		AlbumTracks: item.Album.TotalTracks,
+		IsPlayable:  item.IsPlayable == nil || *item.IsPlayable,
 	}
 
-	if len(item.Artists) > 0 {
-		track.Artist = item.Artists[0].Name
+	for _, a := range item.Artists {
+		track.Artists = append(track.Artists, sanitize(a.Name))
+		track.ArtistIDs = append(track.ArtistIDs, a.ID)
+	}
+	if len(track.Artists) > 0 {
+		track.Artist = track.Artists[0]
 	}
 
-	if len(item.Album.Images) > 0 {
-		track.ArtworkURL = item.Album.Images[0].URL
+	if img, ok := largestImage(item.Album.Images); ok {
+		track.ArtworkURL = img.URL
+	} else {
+		log.Debug("Track has no album images", "track", item.Name)
 	}
 
-	log.Info("Got recently played", "track", track.Name, "artist", track.Artist)
-	return track, nil
+	return track
+}
+
+// largestImage escolhe a imagem de maior resolução. A API costuma enviar
+// da maior para a menor, mas isso não é documentado: compara os tamanhos
+// em vez de confiar na posição. Imagens sem tamanho perdem para qualquer
+// imagem com tamanho.
+//
+// Em empate (mesma área, inclusive 640×300 contra 300×640), vale a que veio
+// primeiro no array: a busca percorre o slice em ordem e só troca de
+// escolha com uma área estritamente maior, então a mesma resposta da API
+// sempre dá a mesma imagem.
+func largestImage(images []image) (image, bool) {
+	best, bestArea := -1, 0
+	for i, img := range images {
+		if img.URL == "" {
+			continue
+		}
+		if area := img.Width * img.Height; best < 0 || area > bestArea {
+			best, bestArea = i, area
+		}
+	}
+	if best < 0 {
+		return image{}, false
+	}
+	return images[best], true
+}
+
+// newPlayedTrack converte uma entrada do histórico em Track.
+func newPlayedTrack(item *playHistoryItem) *Track {
+	track := newTrack(&item.Track)
+	track.PlayedAt = item.PlayedAt
+	return track
+}
+
+// sanitize substitui bytes UTF-8 inválidos por U+FFFD e corta s em
+// maxFieldLength runas.
+// Defensivo: o texto da API vai direto para o terminal, no meio das
+// sequências ANSI, e bytes soltos ali bagunçam a renderização. O corte
+// protege o layout de nomes absurdos antes de qualquer truncamento por
+// largura, que já pressupõe textos de tamanho razoável.
+func sanitize(s string) string {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "\uFFFD")
+	}
+	limit := int(maxFieldLength.Load())
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	n := 0
+	for i := range s {
+		if n == limit {
+			return s[:i] + "…"
+		}
+		n++
+	}
+	return s
+}
+
+// DefaultMaxFieldLength é o tamanho máximo padrão, em runas, dos textos da API.
+const DefaultMaxFieldLength = 200
+
+// maxFieldLength é o tamanho máximo dos textos da API (ver SetMaxFieldLength).
+var maxFieldLength atomic.Int64
+
+func init() {
+	maxFieldLength.Store(DefaultMaxFieldLength)
+}
+
+// SetMaxFieldLength define o tamanho máximo, em runas, de nomes de música,
+// artista, álbum e contexto; textos maiores são cortados com "…".
+// Zero ou negativo desliga o limite. Vale para todos os clientes.
+func SetMaxFieldLength(n int) {
+	maxFieldLength.Store(int64(n))
+}
+
+// CheckAuth verifica se o cliente consegue um access token válido,
+// renovando-o se necessário. Com um token ainda válido, não faz requests.
+func (c *Client) CheckAuth() error {
+	return c.ensureValidToken()
+}
+
+// scopeChecks associa cada endpoint usado pelo cliente ao scope que ele exige.
+//
+// Os comandos do player mudam o que está tocando, então não há como testar
+// o scope deles sem efeito colateral: sem url, ele só é conferido pelo
+// campo scope da resposta do token.
+var scopeChecks = []struct {
+	scope string
+	url   string
+}{
+	{"user-read-currently-playing", "https://api.spotify.com/v1/me/player/currently-playing"},
+	{"user-read-recently-played", "https://api.spotify.com/v1/me/player/recently-played?limit=1"},
+	{"user-read-playback-state", "https://api.spotify.com/v1/me/player/queue"},
+	{"user-modify-playback-state", ""},
+}
+
+// CheckScopes retorna os scopes que faltam no refresh token.
+//
+// Se a resposta do token informou os scopes concedidos, compara com eles
+// sem nenhuma request extra. Senão, chama uma vez cada endpoint de leitura
+// e conta as respostas 403; outros erros não indicam scope faltando e são
+// ignorados.
+func (c *Client) CheckScopes() ([]string, error) {
+	if err := c.ensureValidToken(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	granted := c.scopes
+	c.mu.RUnlock()
+
+	var missing []string
+	for _, check := range scopeChecks {
+		if c.noRecent && check.scope == "user-read-recently-played" {
+			continue
+		}
+		if granted != "" {
+			if !slices.Contains(strings.Fields(granted), check.scope) {
+				missing = append(missing, check.scope)
+			}
+			continue
+		}
+		if check.url == "" {
+			continue
+		}
+		var discard json.RawMessage
+		status, _ := c.get(check.url, &discard)
+		if status == http.StatusForbidden {
+			missing = append(missing, check.scope)
+		}
+	}
+	return missing, nil
 }
 
 // ensureValidToken garante que temos um access token válido.
 // Se expirado ou inexistente, chama refreshAccessToken().
 func (c *Client) ensureValidToken() error {
+	if c.tokenValid() {
+		return nil
+	}
+	return c.refreshAccessToken()
+}
+
+// tokenValid informa se o access token atual ainda pode ser usado.
+func (c *Client) tokenValid() bool {
 	c.mu.RLock()
-	valid := c.accessToken != "" && time.Now().Before(c.tokenExpiry)
-	c.mu.RUnlock()
+	defer c.mu.RUnlock()
+	return c.accessToken != "" && time.Now().Before(c.tokenExpiry)
+}
 
-	if valid {
-		return nil
+// logCall registra o resultado de uma chamada à API numa linha
+// estruturada, com os mesmos campos para todos os endpoints:
+//
+//	endpoint         caminho chamado, sem a query string
+//	status           status HTTP (0 se a request nem chegou a sair)
+//	latency          duração total, incluindo a renovação do token
+//	token_refreshed  se o token precisou ser renovado antes da chamada
+//
+// Sai em debug, então só aparece com LOG_LEVEL=debug.
+func logCall(rawURL string, status int, latency time.Duration, refreshed bool, err error) {
+	endpoint := rawURL
+	if u, perr := url.Parse(rawURL); perr == nil {
+		endpoint = u.Path
 	}
 
-	return c.refreshAccessToken()
+	fields := []any{"endpoint", endpoint, "status", status, "latency", latency, "token_refreshed", refreshed}
+	if err != nil {
+		fields = append(fields, "error", err)
+	}
+	log.Debug("API call", fields...)
 }
 
 // refreshAccessToken obtém um novo access token usando o refresh token.
@@ -282,7 +875,7 @@ func (c *Client) refreshAccessToken() error {
 		log.Error("Token request failed", "error", err)
 		return err
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -296,11 +889,20 @@ func (c *Client) refreshAccessToken() error {
 		return err
 	}
 
+	// Um 200 sem token faria as próximas chamadas enviarem "Bearer " e falharem com 401
+	if tokenResp.AccessToken == "" {
+		log.Error("Token response without access token")
+		return ErrEmptyAccessToken
+	}
+
 	c.mu.Lock()
 	c.accessToken = tokenResp.AccessToken
 	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
+	c.scopes = tokenResp.Scope
 	c.mu.Unlock()
 
+	c.saveTokenCache()
+
 	log.Info("Access token refreshed", "expires_in", tokenResp.ExpiresIn)
 	return nil
 }
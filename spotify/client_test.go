@@ -0,0 +1,440 @@
+package spotify
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// rewriteTransport manda todas as requests para target, mantendo caminho e
+// query: as URLs da API são fixas no cliente. As requests saem por base
+// ou, se nil, pelo transporte padrão.
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	if t.base == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newTestClient cria um cliente que fala com um httptest.Server rodando
+// handler no lugar da API e do endpoint de token, com as opções opts. Com
+// token não vazio, o cliente já começa com esse access token válido.
+func newTestClient(t *testing.T, token string, handler http.Handler, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+
+	opts = append([]Option{WithTransport(rewriteTransport{target: target})}, opts...)
+	c := NewClient("id", "secret", "refresh", opts...)
+	if token != "" {
+		c.accessToken = token
+		c.tokenExpiry = time.Now().Add(time.Hour)
+	}
+	return c
+}
+
+func TestCheckScopesFromToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		scope       string
+		wantMissing []string
+	}{
+		{"all granted", "user-read-currently-playing user-read-recently-played user-read-playback-state user-modify-playback-state", nil},
+		{"no playback control", "user-read-currently-playing user-read-recently-played user-read-playback-state", []string{"user-modify-playback-state"}},
+		{"read only", "user-read-currently-playing", []string{"user-read-recently-played", "user-read-playback-state", "user-modify-playback-state"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiCalls int
+			c := newTestClient(t, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/token" {
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600,"scope":%q}`, tt.scope)
+					return
+				}
+				apiCalls++
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			missing, err := c.CheckScopes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if apiCalls != 0 {
+				t.Errorf("%d API calls with the scopes known, want 0", apiCalls)
+			}
+		})
+	}
+}
+
+func TestCheckScopesProbesReadsOnly(t *testing.T) {
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Sem scopes conhecidos, a verificação não pode mexer na reprodução
+		if r.Method != http.MethodGet {
+			t.Errorf("scope probe = %s %s, want only GETs", r.Method, r.URL.Path)
+		}
+		if r.URL.Path == "/v1/me/player/queue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	missing, err := c.CheckScopes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"user-read-playback-state"}; !slices.Equal(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
+
+func TestRefreshEmptyAccessToken(t *testing.T) {
+	var apiCalls int
+	c := newTestClient(t, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		apiCalls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	if err := c.refreshAccessToken(); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Fatalf("refreshAccessToken = %v, want ErrEmptyAccessToken", err)
+	}
+	if c.tokenValid() {
+		t.Error("empty access token was accepted as valid")
+	}
+
+	// As chamadas da API param no token, sem mandar "Bearer " vazio
+	if _, err := c.GetCurrentlyPlaying(); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Errorf("GetCurrentlyPlaying = %v, want ErrEmptyAccessToken", err)
+	}
+	if apiCalls != 0 {
+		t.Errorf("%d API calls without a token, want 0", apiCalls)
+	}
+}
+
+func TestNewClientTrimsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "id" || secret != "secret" {
+			t.Errorf("basic auth = %q:%q, want id:secret", id, secret)
+		}
+		if got := r.FormValue("refresh_token"); got != "refresh" {
+			t.Errorf("refresh_token = %q, want refresh", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	c := NewClient(" id\n", "secret\r\n", "refresh\n", WithTransport(rewriteTransport{target: target}))
+	if err := c.refreshAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	SetMaxFieldLength(5)
+	t.Cleanup(func() { SetMaxFieldLength(DefaultMaxFieldLength) })
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"valid", "Pão", "Pão"},
+		{"invalid bytes", "a\xffb\xc3", "a�b�"},
+		{"cut by runes", "ççççççç", "ççççç…"},
+		{"invalid then cut", "\xff\xfeabcdef", "�abcd…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitize(tt.in)
+			if got != tt.want {
+				t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("sanitize(%q) = %q is not valid UTF-8", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestCurrentlyPlayingInvalidUTF8(t *testing.T) {
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"is_playing\":true,\"item\":{\"name\":\"Can\xe7\xe3o\",\"album\":{\"name\":\"\xffÁlbum\"}," +
+			"\"artists\":[{\"name\":\"Jo\xe3o\"}]}}"))
+	}))
+
+	track, err := c.GetCurrentlyPlaying()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{track.Name, track.Album, track.Artist} {
+		if !utf8.ValidString(field) || !strings.Contains(field, "�") {
+			t.Errorf("field %q: want valid UTF-8 with U+FFFD", field)
+		}
+	}
+}
+
+func TestRecentlyPlayedWithoutImages(t *testing.T) {
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"played_at":"2024-01-01T12:00:00Z","track":{"name":"Faixa Local",` +
+			`"album":{"name":"","images":[]},"artists":[{"name":"Artista"}]}}]}`))
+	}))
+
+	track, err := c.GetRecentlyPlayed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track == nil || track.Name != "Faixa Local" {
+		t.Fatalf("track = %+v, want Faixa Local", track)
+	}
+	if track.ArtworkURL != "" {
+		t.Errorf("ArtworkURL = %q, want empty", track.ArtworkURL)
+	}
+}
+
+func TestCurrentlyPlayingDiscNumber(t *testing.T) {
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"is_playing":true,"context":{"type":"album","uri":"spotify:album:abc"},` +
+			`"item":{"name":"Faixa","track_number":3,"disc_number":2,` +
+			`"album":{"name":"Álbum Duplo","total_tracks":30},"artists":[{"name":"Artista"}]}}`))
+	}))
+
+	track, err := c.GetCurrentlyPlaying()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.TrackNumber != 3 || track.DiscNumber != 2 || track.AlbumTracks != 30 {
+		t.Errorf("track %d, disc %d, album tracks %d; want 3, 2, 30",
+			track.TrackNumber, track.DiscNumber, track.AlbumTracks)
+	}
+	if track.ContextType != "album" {
+		t.Errorf("ContextType = %q, want album", track.ContextType)
+	}
+}
+
+func TestCurrentlyPlayingDisallows(t *testing.T) {
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"is_playing":true,"item":{"name":"Song"},"actions":{"disallows":` +
+			`{"skipping_prev":true,"resuming":true,"pausing":false,"seeking":true}}}`))
+	}))
+
+	track, err := c.GetCurrentlyPlaying()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ActionResuming, "seeking", ActionSkippingPrev}; !slices.Equal(track.Disallows, want) {
+		t.Errorf("Disallows = %v, want %v", track.Disallows, want)
+	}
+	if track.Allows(ActionSkippingPrev) || !track.Allows(ActionPausing) || !track.Allows(ActionSkippingNext) {
+		t.Errorf("Allows disagrees with Disallows %v", track.Disallows)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	// O servidor só responde depois que o cliente desiste. O corpo é lido
+	// antes: só então o servidor percebe a conexão fechada pelo cliente.
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	t.Cleanup(func() { close(release) })
+
+	t.Run("api", func(t *testing.T) {
+		c := newTestClient(t, "token", slow, WithTimeout(50*time.Millisecond))
+		start := time.Now()
+		_, err := c.GetCurrentlyPlaying()
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("GetCurrentlyPlaying = %v, want a timeout", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("timed out after %v, want about 50ms", elapsed)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		c := newTestClient(t, "", slow, WithTimeout(50*time.Millisecond))
+		var netErr net.Error
+		if err := c.CheckAuth(); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("CheckAuth = %v, want a timeout", err)
+		}
+	})
+}
+
+func TestWithTimeoutNonPositive(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		if c := NewClient("id", "secret", "refresh", WithTimeout(d)); c.httpClient.Timeout != defaultTimeout {
+			t.Errorf("WithTimeout(%v): timeout %v, want the default %v", d, c.httpClient.Timeout, defaultTimeout)
+		}
+	}
+	if c := NewClient("id", "secret", "refresh"); c.httpClient.Timeout != defaultTimeout {
+		t.Errorf("default timeout = %v, want %v", c.httpClient.Timeout, defaultTimeout)
+	}
+}
+
+func TestLargestImage(t *testing.T) {
+	img := func(url string, w, h int) image { return image{URL: url, Width: w, Height: h} }
+
+	tests := []struct {
+		name   string
+		images []image
+		want   string
+	}{
+		{"empty", nil, ""},
+		{"largest last", []image{img("small", 64, 64), img("medium", 300, 300), img("large", 640, 640)}, "large"},
+		{"largest first", []image{img("large", 640, 640), img("small", 64, 64)}, "large"},
+		{"tie keeps the first", []image{img("a", 640, 640), img("b", 640, 640)}, "a"},
+		{"tie by area", []image{img("wide", 640, 300), img("tall", 300, 640)}, "wide"},
+		{"tie after a smaller one", []image{img("small", 64, 64), img("a", 300, 300), img("b", 300, 300)}, "a"},
+		{"unsized loses", []image{img("unsized", 0, 0), img("sized", 64, 64)}, "sized"},
+		{"only unsized", []image{img("a", 0, 0), img("b", 0, 0)}, "a"},
+		{"no URL", []image{img("", 640, 640), img("small", 64, 64)}, "small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A escolha é a mesma em toda execução
+			for range 20 {
+				got, ok := largestImage(tt.images)
+				if ok != (tt.want != "") || got.URL != tt.want {
+					t.Fatalf("largestImage = %q, %v; want %q", got.URL, ok, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestConnectionReuse confere que o token e as chamadas da API passam pelo
+// mesmo transporte, em HTTP/2 e numa única conexão.
+func TestConnectionReuse(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("%s over %s, want HTTP/2", r.URL.Path, r.Proto)
+		}
+		if r.URL.Path == "/api/token" {
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.EnableHTTP2 = true
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+
+	// O transporte do próprio cliente, só confiando no certificado do servidor
+	c := NewClient("id", "secret", "refresh")
+	transport := c.httpClient.Transport.(*http.Transport)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	c.httpClient.Transport = rewriteTransport{target: target, base: transport}
+
+	for range 5 {
+		if _, err := c.GetCurrentlyPlaying(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("%d connections for the token and 5 API calls, want 1", n)
+	}
+}
+
+func TestLongNamesCapped(t *testing.T) {
+	long := strings.Repeat("Nome Enorme ", 500)
+	c := newTestClient(t, "token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"is_playing":true,"item":{"name":%q,"album":{"name":%q},"artists":[{"name":%q}]}}`,
+			long, long, long)
+	}))
+
+	track, err := c.GetCurrentlyPlaying()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{track.Name, track.Album, track.Artist} {
+		if n := utf8.RuneCountInString(field); n != DefaultMaxFieldLength+1 || !strings.HasSuffix(field, "…") {
+			t.Errorf("field with %d runes, want %d plus …", n, DefaultMaxFieldLength)
+		}
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.Host)
+		mu.Unlock()
+		// Recusa o túnel: basta saber que a request passou por aqui
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(proxy.Close)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	c := NewClient("id", "secret", "refresh", WithProxy(proxyURL))
+	if err := c.CheckAuth(); err == nil {
+		t.Fatal("token refresh through a refusing proxy succeeded")
+	}
+	c.accessToken = "token"
+	c.tokenExpiry = time.Now().Add(time.Hour)
+	if _, err := c.GetCurrentlyPlaying(); err == nil {
+		t.Fatal("API call through a refusing proxy succeeded")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"CONNECT accounts.spotify.com:443", "CONNECT api.spotify.com:443"}
+	for _, w := range want {
+		if !slices.Contains(seen, w) {
+			t.Errorf("proxy saw %v, want %q", seen, w)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package spotify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// cachedToken é o formato do arquivo de cache do access token.
+// Key identifica as credenciais que geraram o token (ver tokenCacheKey),
+// para que trocar de conta ou de app não reaproveite o token antigo.
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	Expiry      time.Time `json:"expiry"`
+	Scope       string    `json:"scope,omitempty"`
+	Key         string    `json:"key"`
+}
+
+// tokenCacheKey é um hash do client ID e do refresh token. Guarda-se o
+// hash, não as credenciais, para não expô-las no arquivo de cache.
+func (c *Client) tokenCacheKey() string {
+	sum := sha256.Sum256([]byte(c.clientID + "\x00" + c.refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithTokenCache persiste o access token e sua expiração em path.
+// Se o arquivo tiver um token ainda válido, NewClient o reaproveita em vez
+// de pedir um novo logo na primeira chamada, evitando uma ida ao endpoint
+// de token a cada reinício. Arquivos ausentes, corrompidos, expirados ou
+// gravados com outras credenciais são ignorados.
+//
+// O arquivo é escrito com permissão 0600: o token dá acesso à conta.
+func WithTokenCache(path string) Option {
+	return func(c *Client) {
+		c.tokenCachePath = path
+		c.loadTokenCache()
+	}
+}
+
+// loadTokenCache carrega o token do cache, se ainda válido.
+func (c *Client) loadTokenCache() {
+	data, err := os.ReadFile(c.tokenCachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Warn("Failed to read token cache", "path", c.tokenCachePath, "error", err)
+		return
+	}
+
+	var token cachedToken
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		log.Warn("Ignoring corrupt token cache", "path", c.tokenCachePath, "error", err)
+		return
+	}
+	if token.Key != c.tokenCacheKey() {
+		log.Info("Ignoring token cache from other credentials", "path", c.tokenCachePath)
+		return
+	}
+	if !time.Now().Before(token.Expiry) {
+		log.Debug("Cached token expired", "expiry", token.Expiry)
+		return
+	}
+
+	c.mu.Lock()
+	c.accessToken = token.AccessToken
+	c.tokenExpiry = token.Expiry
+	c.scopes = token.Scope
+	c.mu.Unlock()
+
+	log.Info("Using cached access token", "expires_in", time.Until(token.Expiry).Round(time.Second))
+}
+
+// saveTokenCache grava o token atual no cache, se configurado.
+// Escreve num arquivo temporário e renomeia, para que um crash no meio
+// da escrita não deixe um cache pela metade.
+func (c *Client) saveTokenCache() {
+	if c.tokenCachePath == "" {
+		return
+	}
+
+	c.mu.RLock()
+	data, err := json.Marshal(cachedToken{
+		AccessToken: c.accessToken,
+		Expiry:      c.tokenExpiry,
+		Scope:       c.scopes,
+		Key:         c.tokenCacheKey(),
+	})
+	c.mu.RUnlock()
+	if err != nil {
+		log.Warn("Failed to encode token cache", "error", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.tokenCachePath), ".token-*")
+	if err != nil {
+		log.Warn("Failed to write token cache", "path", c.tokenCachePath, "error", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Warn("Failed to write token cache", "path", c.tokenCachePath, "error", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Warn("Failed to write token cache", "path", c.tokenCachePath, "error", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.tokenCachePath); err != nil {
+		log.Warn("Failed to write token cache", "path", c.tokenCachePath, "error", err)
+	}
+}
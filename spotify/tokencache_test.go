@@ -0,0 +1,96 @@
+package spotify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCache grava um cache de token em dir e retorna o caminho.
+func writeCache(t *testing.T, dir string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, "token.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func cacheJSON(t *testing.T, token cachedToken) []byte {
+	t.Helper()
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTokenCache(t *testing.T) {
+	key := NewClient("id", "secret", "refresh").tokenCacheKey()
+	valid := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantToken string
+	}{
+		{"valid", cacheJSON(t, cachedToken{AccessToken: "cached", Expiry: valid, Key: key}), "cached"},
+		{"corrupt", []byte(`{"access_token": "cach`), ""},
+		{"expired", cacheJSON(t, cachedToken{AccessToken: "cached", Expiry: time.Now().Add(-time.Minute), Key: key}), ""},
+		{"other credentials", cacheJSON(t, cachedToken{AccessToken: "cached", Expiry: valid, Key: "outra-conta"}), ""},
+		{"no key", cacheJSON(t, cachedToken{AccessToken: "cached", Expiry: valid}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCache(t, t.TempDir(), tt.data)
+			c := NewClient("id", "secret", "refresh", WithTokenCache(path))
+			if c.accessToken != tt.wantToken {
+				t.Errorf("accessToken = %q, want %q", c.accessToken, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestTokenCacheKeyDependsOnCredentials(t *testing.T) {
+	base := NewClient("id", "secret", "refresh").tokenCacheKey()
+	if NewClient("other", "secret", "refresh").tokenCacheKey() == base {
+		t.Error("key ignores the client ID")
+	}
+	if NewClient("id", "secret", "other").tokenCacheKey() == base {
+		t.Error("key ignores the refresh token")
+	}
+	if NewClient("id", "other", "refresh").tokenCacheKey() != base {
+		t.Error("key depends on the client secret")
+	}
+}
+
+func TestTokenCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	c := NewClient("id", "secret", "refresh", WithTokenCache(path))
+	c.accessToken = "fresh"
+	c.tokenExpiry = time.Now().Add(time.Hour)
+	c.saveTokenCache()
+
+	if got := NewClient("id", "secret", "refresh", WithTokenCache(path)).accessToken; got != "fresh" {
+		t.Errorf("same credentials: accessToken = %q, want %q", got, "fresh")
+	}
+	if got := NewClient("id", "secret", "rotated", WithTokenCache(path)).accessToken; got != "" {
+		t.Errorf("new refresh token: accessToken = %q, want empty", got)
+	}
+}
+
+func TestTokenCacheKeepsScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	c := NewClient("id", "secret", "refresh", WithTokenCache(path))
+	c.accessToken = "fresh"
+	c.tokenExpiry = time.Now().Add(time.Hour)
+	c.scopes = "user-read-currently-playing"
+	c.saveTokenCache()
+
+	if got := NewClient("id", "secret", "refresh", WithTokenCache(path)).scopes; got != c.scopes {
+		t.Errorf("scopes = %q, want %q", got, c.scopes)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+// sessionLocation retorna o fuso de TZ em environ, o ambiente da sessão
+// SSH, para que "hoje" seja o dia de quem está vendo. Sem TZ, ou com um
+// fuso desconhecido, usa o do servidor.
+func sessionLocation(environ []string) *time.Location {
+	for _, kv := range environ {
+		name, ok := strings.CutPrefix(kv, "TZ=")
+		if !ok {
+			continue
+		}
+		// POSIX permite ":" antes do nome do fuso
+		if loc, err := time.LoadLocation(strings.TrimPrefix(name, ":")); err == nil && name != "" {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// playsToday conta as reproduções de history desde a meia-noite de now
+// em loc até now. O histórico do provider cobre activityWindow, que
+// inclui a meia-noite exceto no dia de 25 horas da troca de horário,
+// quando a primeira hora do dia fica de fora.
+func playsToday(history []*spotify.Track, now time.Time, loc *time.Location) int {
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	n := 0
+	for _, t := range playTimes(history) {
+		if !t.Before(midnight) && !t.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// todayText é o contador do rodapé, ou "" se nada tocou hoje.
+func todayText(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return text.TodayOne
+	default:
+		return fmt.Sprintf(text.Today, n)
+	}
+}
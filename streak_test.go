@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"ssh-portfolio/spotify"
+)
+
+func TestPlaysToday(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, loc)
+	at := func(d time.Duration) *spotify.Track {
+		return &spotify.Track{PlayedAt: now.Add(d)}
+	}
+	history := []*spotify.Track{
+		at(-9 * time.Hour),   // 23h de ontem
+		at(-8*time.Hour - 1), // um instante antes da meia-noite
+		at(-8 * time.Hour),   // meia-noite em ponto
+		at(-time.Hour),       // hoje de manhã
+		at(time.Minute),      // no futuro, relógio adiantado
+		{Name: "Sem data"},   // sem PlayedAt
+	}
+
+	if got := playsToday(history, now, loc); got != 2 {
+		t.Errorf("playsToday = %d, want 2", got)
+	}
+	// Em UTC já são 11h e o dia começou às 21h de ontem em BRT, então
+	// as reproduções de ontem à noite também contam.
+	if got := playsToday(history, now, time.UTC); got != 4 {
+		t.Errorf("playsToday in UTC = %d, want 4", got)
+	}
+}
+
+func TestTodayText(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, ""},
+		{1, text.TodayOne},
+		{3, "🎧 3 músicas hoje"},
+	}
+	for _, tt := range tests {
+		if got := todayText(tt.n); got != tt.want {
+			t.Errorf("todayText(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSessionLocation(t *testing.T) {
+	if got := sessionLocation([]string{"TERM=xterm", "TZ=:America/Sao_Paulo"}); got.String() != "America/Sao_Paulo" {
+		t.Errorf("got %v, want America/Sao_Paulo", got)
+	}
+	for _, env := range [][]string{nil, {"TZ="}, {"TZ=Nowhere/Land"}} {
+		if got := sessionLocation(env); got != time.Local {
+			t.Errorf("sessionLocation(%q) = %v, want time.Local", env, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sync/atomic"
+
+	"ssh-portfolio/albumart"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme agrupa as cores do widget, incluindo o frame e o placeholder da capa,
+// para que texto, bordas e arte combinem entre si.
+type Theme struct {
+	Name       string
+	Primary    lipgloss.Color // Título e borda do widget
+	Text       lipgloss.Color // Nome da música
+	Secondary  lipgloss.Color // Artista
+	Muted      lipgloss.Color // Álbum, rodapé e widget vazio
+	Background lipgloss.Color // Fundo esperado do terminal
+	ArtBorder  lipgloss.Color // Moldura da capa
+
+	// ArtBorderStyle é o estilo da moldura da capa: "rounded", "normal",
+	// "thick" ou "none". Vazio ou desconhecido usa "rounded".
+	ArtBorderStyle string
+
+	PlaceholderFG color.RGBA // Metade superior dos blocos do placeholder
+	PlaceholderBG color.RGBA // Metade inferior dos blocos do placeholder
+}
+
+// themes são os temas embutidos, selecionáveis pela variável THEME.
+var themes = map[string]Theme{
+	"dark": {
+		Name:           "dark",
+		Primary:        lipgloss.Color("#1DB954"),
+		Text:           lipgloss.Color("#FFFFFF"),
+		Secondary:      lipgloss.Color("#B3B3B3"),
+		Muted:          lipgloss.Color("#535353"),
+		Background:     lipgloss.Color("#191414"),
+		ArtBorder:      lipgloss.Color("#535353"),
+		ArtBorderStyle: "rounded",
+		PlaceholderFG:  color.RGBA{60, 60, 60, 255},
+		PlaceholderBG:  color.RGBA{40, 40, 40, 255},
+	},
+	"light": {
+		Name:           "light",
+		Primary:        lipgloss.Color("#148A3D"),
+		Text:           lipgloss.Color("#191414"),
+		Secondary:      lipgloss.Color("#535353"),
+		Muted:          lipgloss.Color("#8E8E8E"),
+		Background:     lipgloss.Color("#FFFFFF"),
+		ArtBorder:      lipgloss.Color("#B3B3B3"),
+		ArtBorderStyle: "normal",
+		PlaceholderFG:  color.RGBA{215, 215, 215, 255},
+		PlaceholderBG:  color.RGBA{235, 235, 235, 255},
+	},
+}
+
+// defaultTheme é o tema usado quando THEME não está definido ou é desconhecido.
+const defaultTheme = "dark"
+
+// themeStyles são os estilos lipgloss derivados de um Theme.
+type themeStyles struct {
+	theme Theme
+	gen   uint64 // Incrementada a cada setTheme
+
+	title       lipgloss.Style
+	trackName   lipgloss.Style
+	artist      lipgloss.Style
+	album       lipgloss.Style
+	footer      lipgloss.Style
+	loading     lipgloss.Style
+	widget      lipgloss.Style
+	emptyWidget lipgloss.Style
+	artFrame    lipgloss.Style
+	banner      lipgloss.Style
+}
+
+// newThemeStyles constrói os estilos do widget para o tema t.
+func newThemeStyles(t Theme) themeStyles {
+	return themeStyles{
+		theme: t,
+
+		title: lipgloss.NewStyle().
+			Foreground(t.Primary).
+			Bold(true),
+
+		trackName: lipgloss.NewStyle().
+			Foreground(t.Text).
+			Bold(true),
+
+		artist: lipgloss.NewStyle().
+			Foreground(t.Secondary),
+
+		album: lipgloss.NewStyle().
+			Foreground(t.Muted).
+			Italic(true),
+
+		footer: lipgloss.NewStyle().
+			Foreground(t.Muted),
+
+		loading: lipgloss.NewStyle().
+			Foreground(t.Primary).
+			Bold(true),
+
+		widget: lipgloss.NewStyle().
+			Border(lipgloss.DoubleBorder()).
+			BorderForeground(t.Primary).
+			Padding(1, 2),
+
+		emptyWidget: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.Muted).
+			Padding(1, 2).
+			Foreground(t.Muted),
+
+		artFrame: artFrameStyle(t),
+
+		banner: lipgloss.NewStyle().
+			Foreground(t.Background).
+			Background(t.Secondary).
+			Bold(true).
+			Padding(0, 1),
+	}
+}
+
+// artFrameStyle monta a moldura da capa conforme t.ArtBorderStyle.
+func artFrameStyle(t Theme) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch t.ArtBorderStyle {
+	case "none":
+		return style
+	case "normal":
+		style = style.Border(lipgloss.NormalBorder())
+	case "thick":
+		style = style.Border(lipgloss.ThickBorder())
+	default:
+		style = style.Border(lipgloss.RoundedBorder())
+	}
+	return style.BorderForeground(t.ArtBorder)
+}
+
+// placeholder retorna as cores do placeholder da capa no formato do albumart.
+func (t Theme) placeholder() albumart.PlaceholderColors {
+	return albumart.PlaceholderColors{FG: t.PlaceholderFG, BG: t.PlaceholderBG}
+}
+
+// artBorderRGBA retorna a cor da moldura da capa como RGBA, para os cantos
+// arredondados da arte.
+func (t Theme) artBorderRGBA() color.RGBA {
+	return hexRGBA(t.ArtBorder)
+}
+
+// hexRGBA converte uma cor lipgloss "#RRGGBB" em RGBA.
+// Cores em outro formato (ex.: índices ANSI) viram preto.
+func hexRGBA(c lipgloss.Color) color.RGBA {
+	rgba := color.RGBA{A: 255}
+	fmt.Sscanf(string(c), "#%02x%02x%02x", &rgba.R, &rgba.G, &rgba.B)
+	return rgba
+}
+
+// activeStyles são os estilos do tema ativo, compartilhados por todas as
+// sessões. Trocados inteiros por setTheme, inclusive com sessões abertas
+// (SIGHUP com THEME_FILE), por isso atrás de um ponteiro atômico.
+var activeStyles atomic.Pointer[themeStyles]
+
+func init() {
+	setTheme(themes[defaultTheme])
+}
+
+// styles retorna os estilos do tema ativo.
+func styles() *themeStyles {
+	return activeStyles.Load()
+}
+
+// setTheme troca o tema de todas as sessões. Cada troca ganha uma geração
+// nova, para que caches de renderização percebam a mudança mesmo quando
+// o nome do tema é o mesmo (ex.: o arquivo do tema foi editado).
+func setTheme(t Theme) {
+	s := newThemeStyles(t)
+	if prev := activeStyles.Load(); prev != nil {
+		s.gen = prev.gen + 1
+	}
+	activeStyles.Store(&s)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestArtFrameStyle(t *testing.T) {
+	tests := []struct {
+		style string
+		want  lipgloss.Border
+	}{
+		{"rounded", lipgloss.RoundedBorder()},
+		{"normal", lipgloss.NormalBorder()},
+		{"thick", lipgloss.ThickBorder()},
+		{"", lipgloss.RoundedBorder()},
+		{"dashed", lipgloss.RoundedBorder()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			theme := Theme{ArtBorder: lipgloss.Color("#123456"), ArtBorderStyle: tt.style}
+			style := artFrameStyle(theme)
+			if got := style.GetBorderStyle(); got != tt.want {
+				t.Errorf("border = %+v, want %+v", got, tt.want)
+			}
+			for side, c := range map[string]lipgloss.TerminalColor{
+				"top":    style.GetBorderTopForeground(),
+				"bottom": style.GetBorderBottomForeground(),
+				"left":   style.GetBorderLeftForeground(),
+				"right":  style.GetBorderRightForeground(),
+			} {
+				if c != theme.ArtBorder {
+					t.Errorf("%s border color = %v, want %v", side, c, theme.ArtBorder)
+				}
+			}
+
+			lines := strings.Split(style.Render("x"), "\n")
+			if len(lines) != 3 || !strings.Contains(lines[0], tt.want.TopLeft) || !strings.Contains(lines[2], tt.want.BottomRight) {
+				t.Errorf("rendered frame = %q, want %q and %q corners", lines, tt.want.TopLeft, tt.want.BottomRight)
+			}
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		style := artFrameStyle(Theme{ArtBorder: lipgloss.Color("#123456"), ArtBorderStyle: "none"})
+		if style.GetHorizontalFrameSize() != 0 || style.GetVerticalFrameSize() != 0 {
+			t.Errorf("frame size %dx%d, want no frame", style.GetHorizontalFrameSize(), style.GetVerticalFrameSize())
+		}
+		if got := style.Render("x"); got != "x" {
+			t.Errorf("rendered = %q, want the art alone", got)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// themeFile é o formato de THEME_FILE, em JSON. Cores são "#RRGGBB";
+// campos ausentes herdam do tema base (o embutido com o nome de Base, ou
+// o padrão), então o arquivo só precisa trazer o que muda:
+//
+//	{"name": "meu", "base": "dark", "primary": "#FF5500"}
+type themeFile struct {
+	Name           string `json:"name"`
+	Base           string `json:"base"`
+	Primary        string `json:"primary"`
+	Text           string `json:"text"`
+	Secondary      string `json:"secondary"`
+	Muted          string `json:"muted"`
+	Background     string `json:"background"`
+	ArtBorder      string `json:"art_border"`
+	ArtBorderStyle string `json:"art_border_style"`
+	PlaceholderFG  string `json:"placeholder_fg"`
+	PlaceholderBG  string `json:"placeholder_bg"`
+}
+
+// hexColor é o único formato de cor aceito no arquivo; hexRGBA trata
+// qualquer outro como preto, o que esconderia um erro de digitação.
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// artBorderStyles são os valores válidos de art_border_style (ver artFrameStyle).
+var artBorderStyles = map[string]bool{"": true, "rounded": true, "normal": true, "thick": true, "none": true}
+
+// loadThemeFile lê e valida o tema em path. Qualquer campo inválido faz
+// o arquivo inteiro ser rejeitado, para nunca aplicar um tema pela metade.
+func loadThemeFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var f themeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Theme{}, fmt.Errorf("decodificando %s: %w", path, err)
+	}
+
+	base := themes[defaultTheme]
+	if f.Base != "" {
+		b, ok := themes[f.Base]
+		if !ok {
+			return Theme{}, fmt.Errorf("tema base desconhecido: %q", f.Base)
+		}
+		base = b
+	}
+
+	t := base
+	t.Name = f.Name
+	if t.Name == "" {
+		t.Name = path
+	}
+	var errs []error
+	for _, c := range []struct {
+		field string
+		value string
+		dst   *lipgloss.Color
+	}{
+		{"primary", f.Primary, &t.Primary},
+		{"text", f.Text, &t.Text},
+		{"secondary", f.Secondary, &t.Secondary},
+		{"muted", f.Muted, &t.Muted},
+		{"background", f.Background, &t.Background},
+		{"art_border", f.ArtBorder, &t.ArtBorder},
+	} {
+		if c.value == "" {
+			continue
+		}
+		if !hexColor.MatchString(c.value) {
+			errs = append(errs, fmt.Errorf("%s: cor inválida %q", c.field, c.value))
+			continue
+		}
+		*c.dst = lipgloss.Color(c.value)
+	}
+	for _, c := range []struct {
+		field string
+		value string
+		dst   *color.RGBA
+	}{
+		{"placeholder_fg", f.PlaceholderFG, &t.PlaceholderFG},
+		{"placeholder_bg", f.PlaceholderBG, &t.PlaceholderBG},
+	} {
+		if c.value == "" {
+			continue
+		}
+		if !hexColor.MatchString(c.value) {
+			errs = append(errs, fmt.Errorf("%s: cor inválida %q", c.field, c.value))
+			continue
+		}
+		*c.dst = hexRGBA(lipgloss.Color(c.value))
+	}
+	if f.ArtBorderStyle != "" {
+		if !artBorderStyles[f.ArtBorderStyle] {
+			errs = append(errs, fmt.Errorf("art_border_style: estilo desconhecido %q", f.ArtBorderStyle))
+		}
+		t.ArtBorderStyle = f.ArtBorderStyle
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Theme{}, err
+	}
+	return t, nil
+}
+
+// watchThemeFile relê path a cada SIGHUP. Um arquivo inválido é logado e
+// o tema atual continua; um válido vale para todas as sessões abertas:
+// textos e moldura no próximo quadro, a capa na próxima atualização do
+// provider.
+func watchThemeFile(path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			t, err := loadThemeFile(path)
+			if err != nil {
+				log.Error("Tema inválido, mantendo o atual", "path", path, "error", err)
+				continue
+			}
+			setTheme(t)
+			log.Info("Tema recarregado", "path", path, "theme", t.Name)
+		}
+	}()
+}
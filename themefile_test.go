@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// writeTheme grava data em um THEME_FILE temporário e devolve o caminho.
+func writeTheme(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "theme.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	path := writeTheme(t, `{"name": "meu", "base": "light", "primary": "#FF5500", "placeholder_fg": "#102030"}`)
+	got, err := loadThemeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := themes["light"]
+	want.Name = "meu"
+	want.Primary = lipgloss.Color("#FF5500")
+	want.PlaceholderFG = hexRGBA("#102030")
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadThemeFileInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string // Trecho esperado no erro
+	}{
+		{"json", `{"primary": `, "decodificando"},
+		{"base", `{"base": "neon"}`, "tema base desconhecido"},
+		{"color name", `{"primary": "red"}`, "primary"},
+		{"short hex", `{"muted": "#FFF"}`, "muted"},
+		{"placeholder", `{"placeholder_bg": "#GG0000"}`, "placeholder_bg"},
+		{"border style", `{"art_border_style": "double"}`, "art_border_style"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadThemeFile(writeTheme(t, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	// Todos os campos errados aparecem juntos, não só o primeiro
+	_, err := loadThemeFile(writeTheme(t, `{"primary": "x", "text": "y"}`))
+	if err == nil || !strings.Contains(err.Error(), "primary") || !strings.Contains(err.Error(), "text") {
+		t.Errorf("error = %v, want both fields", err)
+	}
+}
+
+func TestWatchThemeFileReloads(t *testing.T) {
+	prev := styles().theme
+	t.Cleanup(func() { setTheme(prev) })
+
+	path := writeTheme(t, `{"name": "arquivo", "primary": "#112233"}`)
+	watchThemeFile(path)
+	hup := func() {
+		t.Helper()
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gen := styles().gen
+	hup()
+	eventually(t, "the theme to reload", func() bool { return styles().gen > gen })
+	if got := styles().theme; got.Name != "arquivo" || got.Primary != "#112233" {
+		t.Fatalf("theme after SIGHUP = %+v", got)
+	}
+
+	// Um arquivo inválido mantém o tema atual
+	gen = styles().gen
+	if err := os.WriteFile(path, []byte(`{"primary": "verde"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hup()
+	time.Sleep(50 * time.Millisecond)
+	if got := styles(); got.gen != gen || got.theme.Name != "arquivo" {
+		t.Errorf("invalid file replaced the theme with %+v", got.theme)
+	}
+}
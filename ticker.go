@@ -0,0 +1,92 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tickerID identifica um ticker independente do model.
+// Cada ticker tem seu próprio intervalo e seu próprio tea.Cmd, então
+// parar ou reiniciar um deles não afeta os demais.
+type tickerID int
+
+// A busca de dados não é um ticker da sessão: ela roda no Provider.
+const (
+	tickerWatchers   tickerID = iota // Atualiza o contador de sessões (só para o dono)
+	tickerTransition                 // Avança a transição entre músicas
+	tickerProgress                   // Avança a barra de progresso enquanto a música toca
+	tickerLoading                    // Anima as reticências da tela de carregamento
+	tickerEqualizer                  // Anima o equalizador do widget vazio
+	tickerAttract                    // Avança o modo atração
+	tickerFlash                      // Desfaz o flash da troca de música
+	tickerFade                       // Avança o fade-in da capa recém-carregada
+	tickerWelcome                    // Tira as boas-vindas da tela
+	tickerNotice                     // Apaga o aviso do rodapé
+	numTickers
+)
+
+// tickerMsg é emitido a cada disparo de um ticker.
+//
+// gen é a geração do ticker no momento em que o disparo foi agendado.
+// start e stop incrementam a geração, então disparos agendados antes
+// deles chegam com gen antiga e são descartados por tickers.accept.
+type tickerMsg struct {
+	id   tickerID
+	gen  int
+	time time.Time
+}
+
+// ticker guarda o estado de um ticker do model.
+type ticker struct {
+	interval time.Duration
+	gen      int
+	running  bool
+}
+
+// tickers é o conjunto de tickers do model, indexado por tickerID.
+// É um array (e não um map) para ser copiado junto com o model.
+type tickers [numTickers]ticker
+
+// start (re)inicia o ticker id com o intervalo d e retorna o Cmd do primeiro disparo.
+func (ts *tickers) start(id tickerID, d time.Duration) tea.Cmd {
+	t := &ts[id]
+	t.interval = d
+	t.gen++
+	t.running = true
+	return t.schedule(id)
+}
+
+// stop para o ticker id. Disparos já agendados serão ignorados.
+func (ts *tickers) stop(id tickerID) {
+	t := &ts[id]
+	t.gen++
+	t.running = false
+}
+
+// running informa se o ticker id está ativo.
+func (ts *tickers) running(id tickerID) bool {
+	return ts[id].running
+}
+
+// accept informa se msg pertence à geração atual de um ticker ativo.
+func (ts *tickers) accept(msg tickerMsg) bool {
+	t := ts[msg.id]
+	return t.running && t.gen == msg.gen
+}
+
+// next agenda o próximo disparo do ticker id.
+// Deve ser chamado ao tratar um tickerMsg aceito.
+func (ts *tickers) next(id tickerID) tea.Cmd {
+	if !ts[id].running {
+		return nil
+	}
+	return ts[id].schedule(id)
+}
+
+func (t *ticker) schedule(id tickerID) tea.Cmd {
+	gen := t.gen
+	return tea.Tick(t.interval, func(now time.Time) tea.Msg {
+		return tickerMsg{id: id, gen: gen, time: now}
+	})
+}
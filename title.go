@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/log"
+)
+
+// titleTemplate é o título do widget definido em TITLE_TEMPLATE, com os
+// campos {name}, {artist} e {track}. Vazio usa text.Title.
+var titleTemplate string
+
+// ownerName é o valor de {name}: OWNER_NAME ou, sem ela, o nome de
+// exibição do perfil do Spotify, buscado em segundo plano no boot.
+var ownerName atomic.Value // string
+
+// widgetTitle resolve o título do widget para track (que pode ser nil).
+//
+// Se algum campo usado no template estiver vazio (sem música, perfil ainda
+// não carregado...), usa o título padrão do idioma em vez de mostrar um
+// título pela metade, como "🎧 's vibes".
+func widgetTitle(track *spotify.Track) string {
+	tmpl := titleTemplate
+	if tmpl == "" {
+		return text.Title
+	}
+
+	name, _ := ownerName.Load().(string)
+	fields := map[string]string{"{name}": name}
+	if track != nil {
+		fields["{artist}"] = track.Artist
+		fields["{track}"] = track.Name
+	}
+
+	var pairs []string
+	for _, key := range []string{"{name}", "{artist}", "{track}"} {
+		if !strings.Contains(tmpl, key) {
+			continue
+		}
+		if fields[key] == "" {
+			return text.Title
+		}
+		pairs = append(pairs, key, fields[key])
+	}
+	// Um único Replacer: um nome de música contendo "{artist}" não é expandido de novo
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// loadOwnerName busca o nome do perfil do Spotify para {name}, se o
+// template usar o campo e OWNER_NAME não tiver sido definida.
+func loadOwnerName(client *spotify.Client) {
+	if !strings.Contains(titleTemplate, "{name}") || ownerName.Load() != nil {
+		return
+	}
+	name, err := client.GetDisplayName()
+	if err != nil {
+		log.Warn("Não foi possível buscar o nome do perfil do Spotify", "error", err)
+		return
+	}
+	ownerName.Store(name)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"ssh-portfolio/spotify"
+)
+
+// withTitle liga o template de título e o nome do dono durante o teste.
+func withTitle(t *testing.T, tmpl, name string) {
+	t.Helper()
+	prevTmpl, prevName := titleTemplate, ownerName
+	titleTemplate = tmpl
+	ownerName = atomic.Value{}
+	if name != "" {
+		ownerName.Store(name)
+	}
+	t.Cleanup(func() { titleTemplate, ownerName = prevTmpl, prevName })
+}
+
+func TestWidgetTitle(t *testing.T) {
+	full := &spotify.Track{Name: "Faixa", Artist: "Artista", Album: "Álbum"}
+
+	tests := []struct {
+		name  string
+		tmpl  string
+		owner string
+		track *spotify.Track
+		want  string
+	}{
+		{"no template", "", "Ana", full, text.Title},
+		{"all fields", "🎧 {name} · {artist} · {track}", "Ana", full, "🎧 Ana · Artista · Faixa"},
+		{"missing owner", "🎧 {name}'s vibes", "", full, text.Title},
+		{"missing artist", "{artist} — {track}", "Ana", &spotify.Track{Name: "Faixa", Album: "Álbum"}, text.Title},
+		{"missing track name", "{artist} — {track}", "Ana", &spotify.Track{Artist: "Artista", Album: "Álbum"}, text.Title},
+		{"no track", "{track}", "Ana", nil, text.Title},
+		// Campos fora do template não importam, nem o álbum, que não é um campo
+		{"unused field missing", "{name} ouvindo", "Ana", &spotify.Track{}, "Ana ouvindo"},
+		{"missing album", "{artist} — {track}", "Ana", &spotify.Track{Name: "Faixa", Artist: "Artista"}, "Artista — Faixa"},
+		{"no reexpansion", "{track} por {artist}", "Ana", &spotify.Track{Name: "{artist}", Artist: "Artista"}, "{artist} por Artista"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withTitle(t, tt.tmpl, tt.owner)
+			if got := widgetTitle(tt.track); got != tt.want {
+				t.Errorf("widgetTitle = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-portfolio/albumart"
+	"ssh-portfolio/spotify"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// viewModel monta uma sessão tocando uma música com capa e histórico.
+func viewModel() model {
+	now := time.Now()
+	var history []*spotify.Track
+	for i := range 30 {
+		history = append(history, &spotify.Track{Name: "Song", PlayedAt: now.Add(-time.Duration(i) * 40 * time.Minute)})
+	}
+	track := &spotify.Track{
+		Name:       "Song",
+		Artist:     "Artist",
+		Album:      "Album",
+		IsPlaying:  true,
+		IsPlayable: true,
+		DurationMs: 200000,
+		ProgressMs: 50000,
+	}
+
+	m := newModel(120, 40, nil, true)
+	m.currentTrack = track
+	m.history = history
+	m.art = albumart.RenderGenerated("seed", artWidth, artHeight, albumart.Options{})
+	m.artFor = artKey(track)
+	return m
+}
+
+// inTransition coloca m no meio da transição vinda de outra música.
+func inTransition(m model) model {
+	m.prevTrack = &spotify.Track{Name: "Previous"}
+	m.prevArt = albumart.RenderGenerated("previous", artWidth, artHeight, albumart.Options{})
+	m.transition = transitionFrames / 2
+	return m
+}
+
+func TestViewStable(t *testing.T) {
+	models := map[string]model{
+		"steady":     viewModel(),
+		"transition": inTransition(viewModel()),
+	}
+	for name, m := range models {
+		t.Run(name, func(t *testing.T) {
+			if first, second := m.View(), m.View(); first != second {
+				t.Error("View changed without any change in state")
+			}
+		})
+	}
+}
+
+func TestFooterCacheActivity(t *testing.T) {
+	now := time.Now()
+	history := []*spotify.Track{{Name: "Song", PlayedAt: now.Add(-time.Hour)}}
+
+	var c footerCache
+	spark, today := c.activity(history, now, time.Local)
+	wantSpark, wantToday := (*footerCache)(nil).activity(history, now, time.Local)
+	if spark != wantSpark || today != wantToday {
+		t.Fatalf("activity = %q, %q; want %q, %q", spark, today, wantSpark, wantToday)
+	}
+
+	// Um histórico novo recalcula mesmo dentro do mesmo minuto
+	history = append([]*spotify.Track{{Name: "Other", PlayedAt: now.Add(-time.Minute)}}, history...)
+	_, wantToday = (*footerCache)(nil).activity(history, now, time.Local)
+	if _, today = c.activity(history, now, time.Local); today != wantToday {
+		t.Errorf("today = %q after a new play, want %q", today, wantToday)
+	}
+}
+
+// BenchmarkView mede as alocações de quadros seguidos sem mudança de
+// estado, e os de uma transição, em que só muda o passo da mistura.
+func BenchmarkView(b *testing.B) {
+	b.Run("steady", func(b *testing.B) {
+		m := viewModel()
+		b.ReportAllocs()
+		for b.Loop() {
+			m.View()
+		}
+	})
+	b.Run("transition", func(b *testing.B) {
+		m := inTransition(viewModel())
+		b.ReportAllocs()
+		for i := 0; b.Loop(); i++ {
+			m.transition = i%(transitionFrames-1) + 1
+			m.View()
+		}
+	})
+}
+
+func TestArtFadeIn(t *testing.T) {
+	m := viewModel()
+	final := m.art
+	m.art, m.artFor = "", ""
+	placeholder, _ := m.currentArt()
+
+	next, cmd := m.Update(artMsg{key: artKey(m.currentTrack), art: final})
+	m = next.(model)
+	if m.fade != 1 || cmd == nil {
+		t.Fatalf("fade = %d after the art arrived, want 1 with a tick", m.fade)
+	}
+
+	seen := map[string]bool{placeholder: true}
+	for step := 1; step < fadeFrames; step++ {
+		art, _ := m.currentArt()
+		if seen[art] || art == final {
+			t.Fatalf("fade step %d repeats a frame or is already the art", step)
+		}
+		seen[art] = true
+
+		next, _ = m.Update(tickerMsg{id: tickerFade, gen: m.tickers[tickerFade].gen})
+		m = next.(model)
+	}
+
+	if art, _ := m.currentArt(); m.fade != 0 || art != final {
+		t.Errorf("after %d frames: fade %d, art is final %v", fadeFrames, m.fade, art == final)
+	}
+	if m.tickers[tickerFade].running {
+		t.Error("fade ticker still running")
+	}
+}
+
+func TestArtFadeIgnoresStaleArt(t *testing.T) {
+	m := viewModel()
+	next, cmd := m.Update(artMsg{key: "outra música", art: "x"})
+	if m2 := next.(model); m2.fade != 0 || m2.art != m.art || cmd != nil {
+		t.Error("art for another track started a fade")
+	}
+}
+
+func TestQueuePosition(t *testing.T) {
+	next := &spotify.Track{Name: "Depois"}
+
+	tests := []struct {
+		name                  string
+		position, total, disc int
+		next                  *spotify.Track
+		want                  string
+	}{
+		{"first disc", 3, 12, 1, nil, "3 de 12"},
+		{"second disc", 3, 30, 2, nil, "disco 2 · faixa 3"},
+		{"second disc with next", 3, 30, 2, next, "disco 2 · faixa 3"},
+		{"single", 1, 1, 1, nil, ""},
+		{"single with next", 1, 1, 1, next, "próxima: Depois"},
+		{"unknown position", 0, 0, 0, next, "próxima: Depois"},
+		{"position past total", 13, 12, 1, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queuePosition(tt.position, tt.total, tt.disc, tt.next); got != tt.want {
+				t.Errorf("queuePosition(%d, %d, %d) = %q, want %q", tt.position, tt.total, tt.disc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelQueuePositionNeedsAlbumContext(t *testing.T) {
+	m := newModel(120, 40, nil, false)
+	m.currentTrack = &spotify.Track{Name: "Faixa", TrackNumber: 3, DiscNumber: 2, AlbumTracks: 30, ContextType: "album"}
+	if got, want := m.queuePosition(), "disco 2 · faixa 3"; got != want {
+		t.Errorf("album context: queuePosition = %q, want %q", got, want)
+	}
+
+	// Numa playlist, o número da faixa no álbum não diz nada
+	m.currentTrack.ContextType = "playlist"
+	if got := m.queuePosition(); got != "" {
+		t.Errorf("playlist context: queuePosition = %q, want empty", got)
+	}
+}
+
+func TestRecentListSnapshot(t *testing.T) {
+	now := time.Now()
+	var history []*spotify.Track
+	for i, name := range []string{"Primeira", "Segunda", "Terceira"} {
+		history = append(history, &spotify.Track{Name: name, Artist: "Artista", PlayedAt: now.Add(time.Duration(i-3) * time.Hour)})
+	}
+	m := viewModel()
+	m.history = history
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = next.(model)
+	view := m.View()
+	if !strings.Contains(view, "Tocadas recentemente") || !strings.Contains(view, "Terceira") {
+		t.Fatalf("h did not open the recent list:\n%s", view)
+	}
+	snapshot := slices.Clone(m.recent)
+
+	// O polling continua, mas a lista aberta não muda
+	newer := &spotify.Track{Name: "Nova", Artist: "Artista", IsPlaying: true, PlayedAt: now}
+	next, _ = m.Update(trackMsg{track: newer, at: now, history: append(slices.Clone(history), newer)})
+	m = next.(model)
+	if !slices.Equal(m.recent, snapshot) {
+		t.Errorf("recent list changed while open: %v, want %v", m.recent, snapshot)
+	}
+	if view := m.View(); strings.Contains(view, "Nova") {
+		t.Errorf("update leaked into the open recent list:\n%s", view)
+	}
+
+	// Fechar e abrir de novo pega o histórico novo
+	for range 2 {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+		m = next.(model)
+	}
+	if len(m.recent) == 0 || m.recent[0].Name != "Nova" {
+		t.Errorf("reopened list starts with %v, want Nova", m.recent)
+	}
+}
+
+func TestContextLine(t *testing.T) {
+	playlist := `"context":{"type":"playlist","uri":"spotify:playlist:abc","href":"https://api.spotify.com/v1/playlists/abc"},`
+	tests := []struct {
+		name    string
+		context string
+		want    bool
+	}{
+		{"playlist", playlist, true},
+		{"no context", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeSpotify(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/token":
+					w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+				case "/v1/playlists/abc":
+					w.Write([]byte(`{"name":"Foco Total"}`))
+				default:
+					w.Write([]byte(`{"is_playing":true,` + tt.context +
+						`"item":{"name":"Song","album":{"name":"Album"},"artists":[{"name":"Artist"}]}}`))
+				}
+			}))
+			track, err := client.GetCurrentlyPlaying()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := viewModel()
+			m.currentTrack = track
+			if got := strings.Contains(m.View(), "de Foco Total"); got != tt.want {
+				t.Errorf("context line shown = %v, want %v:\n%s", got, tt.want, m.View())
+			}
+		})
+	}
+}
+
+func TestZeroWindowSize(t *testing.T) {
+	// Sessão que ainda não recebeu um tamanho válido: continua carregando
+	m := viewModel()
+	m.width, m.height = 0, 0
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 0, Height: 0})
+	m = next.(model)
+	if !m.loading() {
+		t.Fatal("0x0 session is not loading")
+	}
+	if view := m.View(); !strings.HasPrefix(view, styles().loading.Render(text.Loading)) {
+		t.Errorf("0x0 view = %q, want the loading screen", view)
+	}
+
+	// Um 0x0 no meio de um resize mantém o último tamanho válido
+	next, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 0, Height: 0})
+	m = next.(model)
+	if m.width != 120 || m.height != 40 {
+		t.Errorf("size after a 0x0 resize = %dx%d, want 120x40", m.width, m.height)
+	}
+	if view := m.View(); !strings.Contains(view, "Song") {
+		t.Errorf("view after a 0x0 resize lost the widget:\n%s", view)
+	}
+}
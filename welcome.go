@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// defaultWelcomeDuration é por quanto tempo as boas-vindas ficam na tela
+// antes do widget (WELCOME_DURATION).
+const defaultWelcomeDuration = 2 * time.Second
+
+var (
+	// welcomeText é o texto das boas-vindas mostradas ao conectar, ou
+	// vazio para ir direto ao widget. WELCOME=true usa o texto do idioma
+	// (text.Welcome); WELCOME_TEXT define um texto próprio.
+	welcomeText string
+
+	// welcomeDuration é o tempo das boas-vindas na tela.
+	welcomeDuration = defaultWelcomeDuration
+)
+
+// renderWelcome desenha as boas-vindas no lugar do widget.
+func (m model) renderWelcome() string {
+	width := max(m.width-styles().emptyWidget.GetHorizontalFrameSize(), 1)
+	return m.place(styles().emptyWidget.Render(styles().title.Render(truncate(m.welcome, width))))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withWelcome liga as boas-vindas com s durante o teste.
+func withWelcome(t *testing.T, s string) {
+	t.Helper()
+	prev := welcomeText
+	welcomeText = s
+	t.Cleanup(func() { welcomeText = prev })
+}
+
+func TestWelcomeShownWhileLoading(t *testing.T) {
+	withWelcome(t, "Olá!")
+	m := newModel(80, 24, make(chan trackUpdate), false)
+	if !m.loading() {
+		t.Fatal("new model is not loading")
+	}
+	if view := m.View(); !strings.Contains(view, "Olá!") {
+		t.Errorf("view while loading = %q, want the welcome", view)
+	}
+}
+
+func TestWelcomeDismissedByTicker(t *testing.T) {
+	withWelcome(t, "Olá!")
+	m := newModel(80, 24, nil, false)
+
+	next, _ := m.Update(tickerMsg{id: tickerWelcome, gen: m.tickers[tickerWelcome].gen})
+	m = next.(model)
+	if m.welcome != "" || m.tickers[tickerWelcome].running {
+		t.Errorf("welcome = %q, running %v after its tick; want dismissed", m.welcome, m.tickers[tickerWelcome].running)
+	}
+	if strings.Contains(m.View(), "Olá!") {
+		t.Error("view still shows the welcome")
+	}
+}
+
+func TestWelcomeDismissedByKey(t *testing.T) {
+	withWelcome(t, "Olá!")
+	m := newModel(80, 24, nil, false)
+
+	// A tecla só dispensa: não liga o aviso de troca de música
+	notify := m.notify
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = next.(model)
+	if m.welcome != "" || m.tickers[tickerWelcome].running {
+		t.Errorf("welcome = %q after a key, want dismissed", m.welcome)
+	}
+	if m.notify != notify {
+		t.Error("the dismissing key also toggled notifications")
+	}
+}
+
+func TestWelcomeQuit(t *testing.T) {
+	withWelcome(t, "Olá!")
+	_, cmd := newModel(80, 24, nil, false).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if !isQuit(cmd) {
+		t.Error("q during the welcome does not quit")
+	}
+}
+
+func TestWelcomeOff(t *testing.T) {
+	withWelcome(t, "")
+	m := newModel(80, 24, nil, false)
+	if m.welcome != "" || m.tickers[tickerWelcome].running {
+		t.Error("welcome shown without WELCOME")
+	}
+}
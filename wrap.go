@@ -0,0 +1,98 @@
+package main
+
+import (
+	"cmp"
+	"strings"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// textWrapMode define se nome, artistas e álbum quebram em duas linhas em
+// vez de serem cortados com "...".
+type textWrapMode int
+
+const (
+	wrapOff  textWrapMode = iota // Sempre corta (padrão)
+	wrapOn                       // Sempre quebra
+	wrapAuto                     // Quebra quando sobra espaço vertical
+)
+
+// textWrap é o modo de quebra de todas as sessões (TEXT_WRAP).
+var textWrap = wrapOff
+
+// wrapMaxLines é quantas linhas cada campo pode ocupar quebrado.
+const wrapMaxLines = 2
+
+// wrapMinHeight é a altura de terminal a partir da qual wrapAuto quebra o
+// texto nos layouts empilhados, onde cada linha a mais aumenta o widget.
+// No horizontal a capa é mais alta que o texto e a quebra sai de graça.
+const wrapMinHeight = 40
+
+// parseTextWrap converte o valor de TEXT_WRAP.
+func parseTextWrap(s string) (textWrapMode, bool) {
+	switch s {
+	case "off":
+		return wrapOff, true
+	case "on":
+		return wrapOn, true
+	case "auto":
+		return wrapAuto, true
+	}
+	return wrapOff, false
+}
+
+// wrapping informa se o texto do widget quebra no layout dado.
+func (m model) wrapping(layout widgetLayout) bool {
+	switch textWrap {
+	case wrapOn:
+		return true
+	case wrapAuto:
+		return layout == layoutHorizontal || m.height >= wrapMinHeight
+	}
+	return false
+}
+
+// fitText encaixa s em width colunas: cortado com "..." ou, com wrap,
+// quebrado por palavras em até wrapMaxLines linhas, cortando só a última.
+func fitText(s string, width int, wrap bool) string {
+	if !wrap || lipgloss.Width(s) <= width {
+		return truncate(s, width)
+	}
+
+	lines := wrapLines(s, width)
+	if len(lines) > wrapMaxLines {
+		// O que não coube vai para a última linha, que é cortada
+		last := strings.Join(lines[wrapMaxLines-1:], " ")
+		lines = append(lines[:wrapMaxLines-1], truncate(last, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLines quebra s por palavras em linhas de até width colunas, com o
+// word-wrap do lipgloss (palavras maiores que width são partidas).
+func wrapLines(s string, width int) []string {
+	lines := strings.Split(lipgloss.NewStyle().Width(width).Render(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return lines
+}
+
+// artistText é artistLine com quebra: todos os artistas, se couberem em
+// wrapMaxLines linhas; senão o resumo de artistLine ("Artista feat. +2").
+func artistText(track *spotify.Track, width int, wrap bool) string {
+	if !wrap {
+		return artistLine(track, width)
+	}
+	artists := track.Artists
+	if len(artists) == 0 {
+		artists = []string{cmp.Or(track.Artist, "Artista desconhecido")}
+	}
+	all := strings.Join(artists, ", ")
+	if len(artists) == 1 || len(wrapLines(all, width)) <= wrapMaxLines {
+		return fitText(all, width, true)
+	}
+	return artistLine(track, width)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"ssh-portfolio/spotify"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFitText(t *testing.T) {
+	const width = 12
+	long := "Uma música com um nome comprido demais para a coluna"
+
+	if got := fitText(long, width, false); strings.Contains(got, "\n") || lipgloss.Width(got) > width {
+		t.Errorf("without wrap got %q, want one line of at most %d columns", got, width)
+	}
+	if got := fitText("Curta", width, true); got != "Curta" {
+		t.Errorf("short text with wrap = %q, want it unchanged", got)
+	}
+
+	got := fitText(long, width, true)
+	lines := strings.Split(got, "\n")
+	if len(lines) != wrapMaxLines {
+		t.Fatalf("wrapped into %d lines, want %d: %q", len(lines), wrapMaxLines, got)
+	}
+	for _, line := range lines {
+		if w := lipgloss.Width(line); w > width {
+			t.Errorf("line %q is %d columns wide, want at most %d", line, w, width)
+		}
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], "...") {
+		t.Errorf("last line %q was not truncated", lines[len(lines)-1])
+	}
+}
+
+func TestArtistTextWrap(t *testing.T) {
+	const width = 20
+	few := &spotify.Track{Artists: []string{"Artista Um", "Artista Dois"}}
+	if got := artistText(few, width, true); strings.ReplaceAll(got, "\n", " ") != "Artista Um, Artista Dois" {
+		t.Errorf("two artists that fit in two lines = %q, want both names", got)
+	}
+
+	many := &spotify.Track{Artists: []string{"Artista Um", "Artista Dois", "Artista Três", "Artista Quatro"}}
+	if got, want := artistText(many, width, true), artistLine(many, width); got != want {
+		t.Errorf("artists that need more lines = %q, want the summary %q", got, want)
+	}
+}
+
+func TestWrappedWidgetHeight(t *testing.T) {
+	prev := textWrap
+	t.Cleanup(func() { textWrap = prev })
+
+	m := viewModel()
+	m.currentTrack.Name = strings.Repeat("Nome Comprido ", 10)
+	m.currentTrack.Album = strings.Repeat("Álbum Comprido ", 10)
+
+	textWrap = wrapOff
+	cut := lipgloss.Height(m.renderSpotifyWidget())
+	textWrap = wrapOn
+	wrapped := lipgloss.Height(m.renderSpotifyWidget())
+	// Na horizontal a capa é mais alta que o texto: quebrar não aumenta o widget
+	if wrapped != cut {
+		t.Errorf("wrapped widget is %d rows, want %d like the truncated one", wrapped, cut)
+	}
+	assertFits(t, m.View(), m.width)
+
+	// Empilhado, cada campo quebrado ganha uma linha, e só uma
+	m.width = artWidth + 10
+	if layout := chooseLayout(m.width); layout != layoutStacked {
+		t.Fatalf("layout at %d columns = %v, want stacked", m.width, layout)
+	}
+	textWrap = wrapOff
+	cut = lipgloss.Height(m.renderSpotifyWidget())
+	textWrap = wrapOn
+	if wrapped := lipgloss.Height(m.renderSpotifyWidget()); wrapped != cut+2 {
+		t.Errorf("stacked wrapped widget is %d rows, want %d", wrapped, cut+2)
+	}
+	assertFits(t, m.View(), m.width)
+}
+
+func TestWrappingAuto(t *testing.T) {
+	prev := textWrap
+	textWrap = wrapAuto
+	t.Cleanup(func() { textWrap = prev })
+
+	m := newModel(80, wrapMinHeight-1, nil, false)
+	if !m.wrapping(layoutHorizontal) {
+		t.Error("auto does not wrap in the horizontal layout")
+	}
+	if m.wrapping(layoutStacked) {
+		t.Errorf("auto wraps the stacked layout at %d rows", m.height)
+	}
+	m.height = wrapMinHeight
+	if !m.wrapping(layoutStacked) {
+		t.Errorf("auto does not wrap the stacked layout at %d rows", m.height)
+	}
+}